@@ -0,0 +1,141 @@
+// Package authtest provides an in-memory identity platform for testing device code logins offline
+package authtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Server is an httptest server implementing the device code and token endpoints of the
+// Microsoft identity platform. Clients should use Authority as their authority URL.
+type Server struct {
+	*httptest.Server
+
+	// Authority is the authority URL of the server's "organizations" tenant
+	Authority string
+	// PendingPolls is the number of token polls answered with authorization_pending
+	// before the login completes
+	PendingPolls int
+	// Deny makes logins fail as declined by the user
+	Deny bool
+	// ExpiresIn is the lifetime of issued access tokens in seconds
+	ExpiresIn int
+
+	mu            sync.Mutex
+	issued        int
+	polls         int
+	refreshes     int
+	refreshTokens map[string]bool
+}
+
+// NewServer starts a server issuing access tokens valid for an hour.
+// The server is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{ExpiresIn: 3600, refreshTokens: map[string]bool{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /organizations/oauth2/v2.0/devicecode", s.handleDeviceCode)
+	mux.HandleFunc("POST /organizations/oauth2/v2.0/token", s.handleToken)
+
+	s.Server = httptest.NewServer(mux)
+	s.Authority = s.URL + "/organizations"
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Polls returns the number of device code token polls received so far
+func (s *Server) Polls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.polls
+}
+
+// Refreshes returns the number of successful refresh token grants so far
+func (s *Server) Refreshes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshes
+}
+
+// RevokeRefreshTokens makes every refresh token issued so far invalid
+func (s *Server) RevokeRefreshTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.refreshTokens)
+}
+
+func (s *Server) handleDeviceCode(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("client_id") == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "client_id is required.")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user_code":        "ABCD-EFGH",
+		"device_code":      "device-code",
+		"verification_uri": "https://microsoft.com/devicelogin",
+		"message":          "To sign in, enter the code ABCD-EFGH at https://microsoft.com/devicelogin.",
+		"expires_in":       900,
+		"interval":         1,
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.PostFormValue("grant_type") {
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		if r.PostFormValue("device_code") != "device-code" {
+			writeError(w, http.StatusBadRequest, "bad_verification_code", "Unknown device code.")
+			return
+		}
+		s.polls++
+		switch {
+		case s.Deny:
+			writeError(w, http.StatusBadRequest, "authorization_declined", "The user declined the login.")
+		case s.polls <= s.PendingPolls:
+			writeError(w, http.StatusBadRequest, "authorization_pending", "The user has not completed the login yet.")
+		default:
+			s.writeToken(w)
+		}
+	case "refresh_token":
+		token := r.PostFormValue("refresh_token")
+		if !s.refreshTokens[token] {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "The refresh token has expired.")
+			return
+		}
+		delete(s.refreshTokens, token)
+		s.refreshes++
+		s.writeToken(w)
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant type.")
+	}
+}
+
+// writeToken issues a new access and refresh token. The caller must hold s.mu.
+func (s *Server) writeToken(w http.ResponseWriter) {
+	s.issued++
+	refreshToken := fmt.Sprintf("refresh-%d", s.issued)
+	s.refreshTokens[refreshToken] = true
+	writeJSON(w, http.StatusOK, map[string]any{
+		"token_type":    "Bearer",
+		"access_token":  fmt.Sprintf("access-%d", s.issued),
+		"refresh_token": refreshToken,
+		"expires_in":    s.ExpiresIn,
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	keySize = 32
+	// appDir is the directory of the application in the user cache and config directories
+	appDir = "pzsp-teams"
+)
+
+// Session is a signed-in user's token together with the configuration it was issued for,
+// so it can be refreshed without the original command line
+type Session struct {
+	Authority    string    `json:"authority"`
+	ClientID     string    `json:"client_id"`
+	Scopes       []string  `json:"scopes"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// NewSession returns the session of token issued to the client with config
+func NewSession(config Config, token *Token) *Session {
+	return &Session{
+		Authority:    config.Authority,
+		ClientID:     config.ClientID,
+		Scopes:       config.Scopes,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresAt:    token.ExpiresAt,
+	}
+}
+
+// Config returns the configuration the session's token was issued for
+func (s *Session) Config() Config {
+	return Config{Authority: s.Authority, ClientID: s.ClientID, Scopes: s.Scopes}
+}
+
+// FileCache stores a session in a file encrypted with AES-256-GCM. The key is kept
+// unprotected in a separate file, created on first use, so the encryption only obscures
+// the tokens: it keeps them out of casual view and out of copies of the cache file alone,
+// but anyone who can read both files as the owner can decrypt them. Both files are
+// readable by the owner only.
+type FileCache struct {
+	// Path is the encrypted session file
+	Path string
+	// KeyPath is the key file
+	KeyPath string
+}
+
+// DefaultFileCache returns the cache in the user cache directory, with its key in the
+// user config directory
+func DefaultFileCache() (*FileCache, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &FileCache{
+		Path:    filepath.Join(cacheDir, appDir, "token.enc"),
+		KeyPath: filepath.Join(configDir, appDir, "token.key"),
+	}, nil
+}
+
+// Load returns the cached session. It returns an error matching errNotLoggedIn if there is none.
+func (c *FileCache) Load() (*Session, error) {
+	sealed, err := os.ReadFile(c.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errNotLoggedIn
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCacheReadFailed, err)
+	}
+
+	key, err := os.ReadFile(c.KeyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: key file is missing", errCacheCorrupt)
+	}
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("%w: %w", errKeyFailed, keyError(err))
+	}
+	plain, err := decrypt(key, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCacheCorrupt, err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(plain, &session); err != nil {
+		return nil, fmt.Errorf("%w: %w", errCacheCorrupt, err)
+	}
+	return &session, nil
+}
+
+// Save encrypts and stores session, replacing the cached one
+func (c *FileCache) Save(session *Session) error {
+	key, err := c.loadOrCreateKey()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCacheWriteFailed, err)
+	}
+	sealed, err := encrypt(key, plain)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCacheWriteFailed, err)
+	}
+	if err := writePrivateFile(c.Path, sealed); err != nil {
+		return fmt.Errorf("%w: %w", errCacheWriteFailed, err)
+	}
+	return nil
+}
+
+// Clear removes the cached session and its key. It does nothing if there is none.
+func (c *FileCache) Clear() error {
+	for _, path := range []string{c.Path, c.KeyPath} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %w", errCacheWriteFailed, err)
+		}
+	}
+	return nil
+}
+
+// loadOrCreateKey returns the key in KeyPath, creating a random one if there is none.
+// The key is stored as plain bytes, protected only by the file permissions.
+func (c *FileCache) loadOrCreateKey() ([]byte, error) {
+	key, err := os.ReadFile(c.KeyPath)
+	if err == nil && len(key) == keySize {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", errKeyFailed, err)
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("%w: %w", errKeyFailed, err)
+	}
+	if err := writePrivateFile(c.KeyPath, key); err != nil {
+		return nil, fmt.Errorf("%w: %w", errKeyFailed, err)
+	}
+	return key, nil
+}
+
+func keyError(err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.New("key has the wrong size")
+}
+
+// writePrivateFile writes data to path, readable by the owner only, creating parent directories
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// write to a temporary file first so a failed write does not destroy the existing file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// encrypt seals plain with AES-GCM, prefixing the output with the random nonce
+func encrypt(key, plain []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// decrypt opens data sealed by encrypt
+func decrypt(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
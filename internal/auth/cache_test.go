@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) *FileCache {
+	t.Helper()
+	dir := t.TempDir()
+	return &FileCache{Path: filepath.Join(dir, "cache", "token.enc"), KeyPath: filepath.Join(dir, "config", "token.key")}
+}
+
+func TestFileCache_SaveLoad(t *testing.T) {
+	cache := newTestCache(t)
+	session := &Session{
+		Authority: DefaultAuthority, ClientID: DefaultClientID, Scopes: []string{"User.Read"},
+		AccessToken: "access-secret", RefreshToken: "refresh-secret", ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	if err := cache.Save(session); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+	loaded, err := cache.Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if loaded.AccessToken != session.AccessToken || loaded.RefreshToken != session.RefreshToken ||
+		!loaded.ExpiresAt.Equal(session.ExpiresAt) || loaded.Config().ClientID != DefaultClientID {
+		t.Errorf("Load() = %+v, want %+v", loaded, session)
+	}
+
+	sealed, err := os.ReadFile(cache.Path)
+	if err != nil {
+		t.Fatalf("Failed to read cache file: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Errorf("cache file contains tokens in plain text")
+	}
+	for _, path := range []string{cache.Path, cache.KeyPath} {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %v (%v), want 0600", path, info.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestFileCache_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		saved   bool
+		prepare func(t *testing.T, cache *FileCache)
+		wantErr error
+	}{
+		{
+			name:    "nothing cached",
+			prepare: func(*testing.T, *FileCache) {},
+			wantErr: errNotLoggedIn,
+		},
+		{
+			name:  "tampered cache",
+			saved: true,
+			prepare: func(t *testing.T, cache *FileCache) {
+				sealed, _ := os.ReadFile(cache.Path)
+				sealed[len(sealed)-1] ^= 1
+				if err := os.WriteFile(cache.Path, sealed, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: errCacheCorrupt,
+		},
+		{
+			name:  "missing key",
+			saved: true,
+			prepare: func(t *testing.T, cache *FileCache) {
+				if err := os.Remove(cache.KeyPath); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: errCacheCorrupt,
+		},
+		{
+			name:  "cleared",
+			saved: true,
+			prepare: func(t *testing.T, cache *FileCache) {
+				if err := cache.Clear(); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: errNotLoggedIn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestCache(t)
+			if tt.saved {
+				if err := cache.Save(&Session{AccessToken: "access"}); err != nil {
+					t.Fatalf("Save() unexpected error: %v", err)
+				}
+			}
+			tt.prepare(t, cache)
+
+			_, err := cache.Load()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package auth
+
+import "errors"
+
+var (
+	// Flow errors
+	errDeviceCodeFailed  = errors.New("failed to start device code login")
+	errTokenFailed       = errors.New("failed to get token from identity platform")
+	errLoginDeclined     = errors.New("login was declined")
+	errDeviceCodeExpired = errors.New("device code expired before login completed")
+	errNotLoggedIn       = errors.New("not logged in")
+	errSessionExpired    = errors.New("login session expired")
+
+	// Cache errors
+	errCacheReadFailed  = errors.New("failed to read token cache")
+	errCacheWriteFailed = errors.New("failed to write token cache")
+	errCacheCorrupt     = errors.New("token cache cannot be decrypted")
+	errKeyFailed        = errors.New("failed to load token cache key")
+)
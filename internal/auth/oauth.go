@@ -0,0 +1,226 @@
+// Package auth signs users in to Microsoft Entra ID with the OAuth 2.0 device code flow
+// and keeps their tokens in an encrypted cache
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/wait"
+)
+
+// DefaultAuthority is the Microsoft identity platform endpoint for work and school accounts
+const DefaultAuthority = "https://login.microsoftonline.com/organizations"
+
+// DefaultClientID is the public client ID of the Microsoft Graph Command Line Tools application
+const DefaultClientID = "14d82eec-204b-4c2f-b7e8-296a70dab67e"
+
+// DefaultScopes are the Microsoft Graph permissions needed to send messages
+var DefaultScopes = []string{
+	"offline_access", "User.Read", "Team.ReadBasic.All", "Channel.ReadBasic.All",
+	"ChannelMessage.Send", "Chat.ReadWrite",
+}
+
+// Config identifies the identity platform and application to sign in with
+type Config struct {
+	// Authority is the identity platform URL including the tenant, e.g. DefaultAuthority
+	Authority string
+	// ClientID is the application (client) ID of a public client application
+	ClientID string
+	// Scopes are the permissions requested
+	Scopes []string
+}
+
+// DeviceCode is the code the user enters on the verification page to sign in
+type DeviceCode struct {
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	// Message tells the user where to enter the code
+	Message    string `json:"message"`
+	DeviceCode string `json:"device_code"`
+	ExpiresIn  int    `json:"expires_in"`
+	Interval   int    `json:"interval"`
+}
+
+// Token is an access token with the refresh token to renew it
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// tokenResponse is a response of the token endpoint, successful or not
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Client requests tokens from the identity platform
+type Client struct {
+	config     Config
+	httpClient *http.Client
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient returns a Client for config. Empty Authority, ClientID and Scopes take their defaults.
+func NewClient(config Config, httpClient *http.Client) *Client {
+	if config.Authority == "" {
+		config.Authority = DefaultAuthority
+	}
+	config.Authority = strings.TrimSuffix(config.Authority, "/")
+	if config.ClientID == "" {
+		config.ClientID = DefaultClientID
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{config: config, httpClient: httpClient, now: time.Now, sleep: wait.Sleep}
+}
+
+// Config returns the configuration of the client with defaults applied
+func (c *Client) Config() Config {
+	return c.config
+}
+
+// Login signs the user in with the device code flow. prompt is called with the code
+// the user must enter; Login then waits until the user completes or declines the login.
+func (c *Client) Login(ctx context.Context, prompt func(DeviceCode)) (*Token, error) {
+	code, err := c.requestDeviceCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prompt(*code)
+
+	interval := time.Duration(max(code.Interval, 1)) * time.Second
+	deadline := c.now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for {
+		if err := c.sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		if c.now().After(deadline) {
+			return nil, errDeviceCodeExpired
+		}
+
+		response, err := c.requestToken(ctx, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {code.DeviceCode},
+		})
+		if err != nil {
+			return nil, err
+		}
+		switch response.Error {
+		case "":
+			initializers.Logger.Info("Logged in", "authority", c.config.Authority)
+			return c.newToken(response), nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+		case "authorization_declined", "access_denied":
+			return nil, fmt.Errorf("%w: %s", errLoginDeclined, response.ErrorDescription)
+		case "expired_token", "bad_verification_code":
+			return nil, errDeviceCodeExpired
+		default:
+			return nil, fmt.Errorf("%w: %s: %s", errTokenFailed, response.Error, response.ErrorDescription)
+		}
+	}
+}
+
+// Refresh renews an access token with a refresh token
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	response, err := c.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.Error == "invalid_grant" {
+		return nil, fmt.Errorf("%w: %s", errSessionExpired, response.ErrorDescription)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("%w: %s: %s", errTokenFailed, response.Error, response.ErrorDescription)
+	}
+	token := c.newToken(response)
+	if token.RefreshToken == "" {
+		// the identity platform may keep the refresh token unchanged
+		token.RefreshToken = refreshToken
+	}
+	initializers.Logger.Debug("Access token refreshed", "expires_at", token.ExpiresAt)
+	return token, nil
+}
+
+func (c *Client) newToken(response *tokenResponse) *Token {
+	return &Token{
+		AccessToken:  response.AccessToken,
+		RefreshToken: response.RefreshToken,
+		ExpiresAt:    c.now().Add(time.Duration(response.ExpiresIn) * time.Second),
+	}
+}
+
+func (c *Client) requestDeviceCode(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{"client_id": {c.config.ClientID}, "scope": {strings.Join(c.config.Scopes, " ")}}
+	var code DeviceCode
+	status, err := c.postForm(ctx, c.config.Authority+"/oauth2/v2.0/devicecode", form, &code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errDeviceCodeFailed, err)
+	}
+	if status != http.StatusOK || code.DeviceCode == "" {
+		return nil, fmt.Errorf("%w: status %d", errDeviceCodeFailed, status)
+	}
+	return &code, nil
+}
+
+// requestToken posts a token request. Error responses defined by OAuth are returned
+// in the response; other failures are returned as errors.
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*tokenResponse, error) {
+	form.Set("client_id", c.config.ClientID)
+	form.Set("scope", strings.Join(c.config.Scopes, " "))
+
+	var response tokenResponse
+	status, err := c.postForm(ctx, c.config.Authority+"/oauth2/v2.0/token", form, &response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errTokenFailed, err)
+	}
+	if response.Error == "" && (status != http.StatusOK || response.AccessToken == "") {
+		return nil, fmt.Errorf("%w: status %d", errTokenFailed, status)
+	}
+	return &response, nil
+}
+
+// postForm posts form to endpoint and decodes the JSON response into result
+func (c *Client) postForm(ctx context.Context, endpoint string, form url.Values, result any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			initializers.Logger.Warn("Failed to close identity platform response body", "error", err)
+		}
+	}()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/auth/authtest"
+)
+
+// newTestClient returns a Client for server that does not wait between polls
+func newTestClient(server *authtest.Server) (*Client, *[]time.Duration) {
+	client := NewClient(Config{Authority: server.Authority}, nil)
+	var waits []time.Duration
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return client, &waits
+}
+
+func TestClient_Login(t *testing.T) {
+	server := authtest.NewServer(t)
+	server.PendingPolls = 2
+	client, waits := newTestClient(server)
+
+	var prompted DeviceCode
+	token, err := client.Login(context.Background(), func(code DeviceCode) { prompted = code })
+	if err != nil {
+		t.Fatalf("Login() unexpected error: %v", err)
+	}
+	if prompted.UserCode != "ABCD-EFGH" || prompted.Message == "" {
+		t.Errorf("Login() prompted with %+v, want the device code", prompted)
+	}
+	if token.AccessToken != "access-1" || token.RefreshToken != "refresh-1" {
+		t.Errorf("Login() = %+v, want the issued tokens", token)
+	}
+	if until := time.Until(token.ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("Login() token expires in %v, want an hour", until)
+	}
+	if server.Polls() != 3 || len(*waits) != 3 || (*waits)[0] != time.Second {
+		t.Errorf("Login() polled %d times waiting %v, want 3 polls a second apart", server.Polls(), *waits)
+	}
+}
+
+func TestClient_Login_Declined(t *testing.T) {
+	server := authtest.NewServer(t)
+	server.Deny = true
+	client, _ := newTestClient(server)
+
+	_, err := client.Login(context.Background(), func(DeviceCode) {})
+	if !errors.Is(err, errLoginDeclined) {
+		t.Errorf("Login() error = %v, want %v", err, errLoginDeclined)
+	}
+}
+
+func TestClient_Login_Cancelled(t *testing.T) {
+	server := authtest.NewServer(t)
+	server.PendingPolls = 100
+	client := NewClient(Config{Authority: server.Authority}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := client.Login(ctx, func(DeviceCode) { cancel() })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Login() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClient_Refresh(t *testing.T) {
+	server := authtest.NewServer(t)
+	client, _ := newTestClient(server)
+	token, err := client.Login(context.Background(), func(DeviceCode) {})
+	if err != nil {
+		t.Fatalf("Login() unexpected error: %v", err)
+	}
+
+	refreshed, err := client.Refresh(context.Background(), token.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() unexpected error: %v", err)
+	}
+	if refreshed.AccessToken != "access-2" || refreshed.RefreshToken != "refresh-2" {
+		t.Errorf("Refresh() = %+v, want new tokens", refreshed)
+	}
+
+	_, err = client.Refresh(context.Background(), token.RefreshToken)
+	if !errors.Is(err, errSessionExpired) {
+		t.Errorf("Refresh() with a used token error = %v, want %v", err, errSessionExpired)
+	}
+}
+
+func TestNewClient_Defaults(t *testing.T) {
+	config := NewClient(Config{Authority: "https://login.example.com/tenant/"}, nil).Config()
+
+	if config.Authority != "https://login.example.com/tenant" || config.ClientID != DefaultClientID ||
+		len(config.Scopes) != len(DefaultScopes) {
+		t.Errorf("NewClient() config = %+v, want defaults with the trailing slash trimmed", config)
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// expiryMargin is how long before expiry an access token is renewed,
+// so it does not expire while a request is in flight
+const expiryMargin = 2 * time.Minute
+
+// TokenSource provides access tokens from the cached session, refreshing them when
+// they are about to expire. It is safe for concurrent use.
+type TokenSource struct {
+	cache      *FileCache
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu      sync.Mutex
+	session *Session
+}
+
+// NewTokenSource returns a TokenSource for the session in cache.
+// httpClient is used for refresh requests; nil uses a default client.
+func NewTokenSource(cache *FileCache, httpClient *http.Client) *TokenSource {
+	return &TokenSource{cache: cache, httpClient: httpClient, now: time.Now}
+}
+
+// Token returns a valid access token. It returns an error matching errNotLoggedIn if no
+// user is logged in and errSessionExpired if the session can no longer be refreshed.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		session, err := s.cache.Load()
+		if err != nil {
+			return "", err
+		}
+		s.session = session
+	}
+	if s.now().Add(expiryMargin).Before(s.session.ExpiresAt) {
+		return s.session.AccessToken, nil
+	}
+	if s.session.RefreshToken == "" {
+		return "", errSessionExpired
+	}
+
+	client := NewClient(s.session.Config(), s.httpClient)
+	client.now = s.now
+	token, err := client.Refresh(ctx, s.session.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+	refreshed := NewSession(s.session.Config(), token)
+	if err := s.cache.Save(refreshed); err != nil {
+		// the new token is still usable for this run
+		initializers.Logger.Warn("Failed to cache refreshed token", "error", err)
+	}
+	s.session = refreshed
+	return refreshed.AccessToken, nil
+}
+
+// IsNotLoggedIn reports whether err means no user is logged in or the login session expired,
+// so the user has to log in again
+func IsNotLoggedIn(err error) bool {
+	return errors.Is(err, errNotLoggedIn) || errors.Is(err, errSessionExpired)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/auth/authtest"
+)
+
+// loginForTest logs in to server and caches the session
+func loginForTest(t *testing.T, server *authtest.Server, cache *FileCache) {
+	t.Helper()
+	client, _ := newTestClient(server)
+	token, err := client.Login(context.Background(), func(DeviceCode) {})
+	if err != nil {
+		t.Fatalf("Login() unexpected error: %v", err)
+	}
+	if err := cache.Save(NewSession(client.Config(), token)); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+}
+
+func TestTokenSource_Token(t *testing.T) {
+	server := authtest.NewServer(t)
+	cache := newTestCache(t)
+	loginForTest(t, server, cache)
+	source := NewTokenSource(cache, nil)
+
+	token, err := source.Token(context.Background())
+	if err != nil || token != "access-1" {
+		t.Fatalf("Token() = %q, %v, want the cached token", token, err)
+	}
+
+	// an hour later the token has expired and is refreshed once
+	source.now = func() time.Time { return time.Now().Add(time.Hour) }
+	for range 2 {
+		if token, err = source.Token(context.Background()); err != nil || token != "access-2" {
+			t.Fatalf("Token() after expiry = %q, %v, want a refreshed token", token, err)
+		}
+	}
+	if server.Refreshes() != 1 {
+		t.Errorf("server refreshed %d times, want 1", server.Refreshes())
+	}
+	if session, err := cache.Load(); err != nil || session.AccessToken != "access-2" {
+		t.Errorf("cached session = %+v, %v, want the refreshed token", session, err)
+	}
+}
+
+func TestTokenSource_Token_Errors(t *testing.T) {
+	server := authtest.NewServer(t)
+	cache := newTestCache(t)
+	source := NewTokenSource(cache, nil)
+
+	if _, err := source.Token(context.Background()); !errors.Is(err, errNotLoggedIn) || !IsNotLoggedIn(err) {
+		t.Errorf("Token() without login error = %v, want %v", err, errNotLoggedIn)
+	}
+
+	loginForTest(t, server, cache)
+	server.RevokeRefreshTokens()
+	source.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := source.Token(context.Background()); !errors.Is(err, errSessionExpired) || !IsNotLoggedIn(err) {
+		t.Errorf("Token() with a revoked session error = %v, want %v", err, errSessionExpired)
+	}
+}
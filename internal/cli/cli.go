@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pzsp-teams/cli/internal/auth"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/schedule"
+)
+
+// Process exit codes returned by App.Run
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// App is the command line interface.
+// Its fields hold the dependencies used by commands, so tests can replace them.
+type App struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// Getenv looks up environment variables
+	Getenv func(key string) string
+	// TokenCache stores the session of the logged in user, or is nil if there is
+	// no place to store it
+	TokenCache *auth.FileCache
+	// ScheduleDir is the directory of scheduled sends, or empty if sends cannot be scheduled
+	ScheduleDir string
+	// Clock tells the time for scheduled sends; nil is the system clock
+	Clock schedule.Clock
+	// JournalDir is the directory of the delivery journals of sends, or empty to
+	// send without a journal
+	JournalDir string
+	// SentStorePath is the file remembering recently sent messages, or empty to
+	// send without checking for duplicates
+	SentStorePath string
+}
+
+// New returns an App using the process standard streams and environment
+func New() *App {
+	cache, err := auth.DefaultFileCache()
+	if err != nil {
+		initializers.Logger.Warn("No token cache location", "error", err)
+	}
+	var scheduleDir, journalDir, sentStorePath string
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		scheduleDir = filepath.Join(cacheDir, "pzsp-teams", "schedule")
+		journalDir = filepath.Join(cacheDir, "pzsp-teams", "runs")
+		sentStorePath = filepath.Join(cacheDir, "pzsp-teams", "sent.jsonl")
+	}
+	return &App{
+		Stdout:        os.Stdout,
+		Stderr:        os.Stderr,
+		Getenv:        os.Getenv,
+		TokenCache:    cache,
+		ScheduleDir:   scheduleDir,
+		JournalDir:    journalDir,
+		SentStorePath: sentStorePath,
+	}
+}
+
+// command is a single CLI subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+func (a *App) commands() []command {
+	return []command{
+		{name: "login", summary: "Sign in to Microsoft Teams with a device code", run: a.runLogin},
+		{name: "logout", summary: "Remove the cached login", run: a.runLogout},
+		{name: "preview", summary: "Serve rendered messages as web pages that reload on changes", run: a.runPreview},
+		{name: "schedule", summary: "List, cancel and dispatch scheduled sends", run: a.runSchedule},
+		{name: "send", summary: "Render a template for every recipient and send the messages", run: a.runSend},
+		{name: "validate", summary: "Check a template against a data file without sending", run: a.runValidate},
+	}
+}
+
+// Run executes the subcommand named by args[0] with the remaining arguments
+// and returns the process exit code
+func (a *App) Run(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		a.printUsage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+
+	for _, cmd := range a.commands() {
+		if cmd.name != args[0] {
+			continue
+		}
+		initializers.Logger.Debug("Running command", "command", cmd.name, "args", args[1:])
+		err := cmd.run(args[1:])
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, errUsage):
+			return exitUsage
+		default:
+			initializers.Logger.Debug("Command failed", "command", cmd.name, "error", err)
+			fmt.Fprintf(a.Stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	fmt.Fprintf(a.Stderr, "Unknown command %q\n\n", args[0])
+	a.printUsage()
+	return exitUsage
+}
+
+func (a *App) printUsage() {
+	fmt.Fprintln(a.Stderr, "Usage: cli [-v|--verbose] <command> [flags]")
+	fmt.Fprintln(a.Stderr)
+	fmt.Fprintln(a.Stderr, "Commands:")
+	for _, cmd := range a.commands() {
+		fmt.Fprintf(a.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(a.Stderr)
+	fmt.Fprintln(a.Stderr, `Run "cli <command> -h" for the flags of a command.`)
+}
+
+// newFlagSet returns a flag set for the named command that reports errors to Stderr
+func (a *App) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Stderr)
+	return fs
+}
+
+// parseFlags parses args into fs, turning parse failures into errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := parseFlagsWithArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	return nil
+}
+
+// parseFlagsWithArgs parses args into fs like parseFlags, but leaves arguments
+// following the flags in fs.Args()
+func parseFlagsWithArgs(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	return nil
+}
+
+// requireFlags reports a usage error if any of the named string flags is empty
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	fmt.Fprintf(fs.Output(), "missing required flags: %s\n", strings.Join(missing, ", "))
+	fs.Usage()
+	return fmt.Errorf("%w: missing required flags", errUsage)
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApp_NoArguments(t *testing.T) {
+	app, _, stderr := newTestApp()
+
+	if code := app.Run(nil); code != exitUsage {
+		t.Errorf("App.Run() = %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stderr.String(), "validate") {
+		t.Errorf("App.Run() usage does not list commands: %q", stderr.String())
+	}
+}
+
+func TestApp_Help(t *testing.T) {
+	app, _, _ := newTestApp()
+
+	if code := app.Run([]string{"--help"}); code != exitOK {
+		t.Errorf("App.Run() = %d, want %d", code, exitOK)
+	}
+}
+
+func TestApp_UnknownCommand(t *testing.T) {
+	app, _, stderr := newTestApp()
+
+	if code := app.Run([]string{"frobnicate"}); code != exitUsage {
+		t.Errorf("App.Run() = %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stderr.String(), `Unknown command "frobnicate"`) {
+		t.Errorf("App.Run() stderr = %q", stderr.String())
+	}
+}
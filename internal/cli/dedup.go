@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/dedup"
+	"github.com/pzsp-teams/cli/internal/delivery"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+// defaultDedupWindow is how long a sent message is not sent again without --force
+const defaultDedupWindow = 24 * time.Hour
+
+// minSentRetention is how long sent messages are remembered at least,
+// so a longer window on a later run still finds them
+const minSentRetention = 7 * 24 * time.Hour
+
+// openSentStore opens the store of recently sent messages, forgetting messages older
+// than window. Sends are recorded even when deduplication is off, so a later run
+// with a window still finds them. It returns nil if the app has no store.
+func (a *App) openSentStore(window time.Duration) (*dedup.Store, error) {
+	if a.SentStorePath == "" {
+		return nil, nil
+	}
+	store, err := dedup.Open(a.SentStorePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Prune(max(window, minSentRetention)); err != nil {
+		initializers.Logger.Warn("Failed to prune sent message store", "error", err)
+	}
+	return store, nil
+}
+
+// findDuplicates splits off the messages sent to the same recipient within window,
+// returning the messages to send and result rows for the duplicates
+func findDuplicates(store *dedup.Store, messages []templates.Message, meta templates.Metadata, window time.Duration) ([]templates.Message, []sendResult) {
+	var unique []templates.Message
+	var duplicates []sendResult
+	for _, message := range messages {
+		target := message.Target.String()
+		key := dedup.Key(message.Recipient, target, delivery.TeamsMessage(message, meta))
+		sentAt, ok := store.SentWithin(key, window)
+		if !ok {
+			unique = append(unique, message)
+			continue
+		}
+		initializers.Logger.Warn("Not sending duplicate message", "recipient", message.Recipient, "sent_at", sentAt)
+		duplicates = append(duplicates, sendResult{
+			recipient: message.Recipient,
+			target:    target,
+			status:    statusDuplicate,
+			detail:    "already sent at " + sentAt.Local().Format(time.DateTime) + ", pass --force to send again",
+		})
+	}
+	return unique, duplicates
+}
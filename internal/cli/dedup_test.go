@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSend_RefusesDuplicates(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{"project": {"name": "team", "_target": "chat:19:project@thread.v2"}}`)
+	changed := writeFile(t, dir, "changed.json", `{"project": {"name": "all", "_target": "chat:19:project@thread.v2"}}`)
+	other := writeFile(t, dir, "other.json", `{"project": {"name": "others", "_target": "chat:19:project@thread.v2"}}`)
+	storePath := filepath.Join(t.TempDir(), "sent.jsonl")
+	send := func(extra ...string) (int, string) {
+		app, stdout, _ := newTestApp()
+		app.SentStorePath = storePath
+		args := append([]string{"send", "--template", tmpl, "--token", "secret", "--graph-url", server.URL}, extra...)
+		return app.Run(args), stdout.String()
+	}
+
+	// the steps run in order against the same store
+	steps := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantSent int
+	}{
+		{name: "first send", args: []string{"--data", data}, wantCode: exitOK, wantSent: 1},
+		{name: "repeated send", args: []string{"--data", data}, wantCode: exitFailure, wantSent: 1},
+		{name: "changed content", args: []string{"--data", changed}, wantCode: exitOK, wantSent: 2},
+		{name: "forced send", args: []string{"--data", data, "--force"}, wantCode: exitOK, wantSent: 3},
+		{name: "no dedup window", args: []string{"--data", data, "--dedup-window", "0"}, wantCode: exitOK, wantSent: 4},
+		{name: "recorded without dedup window", args: []string{"--data", other, "--dedup-window", "0"}, wantCode: exitOK, wantSent: 5},
+		{name: "duplicate of send without dedup window", args: []string{"--data", other}, wantCode: exitFailure, wantSent: 5},
+	}
+	for _, step := range steps {
+		code, stdout := send(step.args...)
+		if code != step.wantCode || len(server.Messages()) != step.wantSent {
+			t.Fatalf("%s = %d with %d messages sent, want %d with %d sent", step.name, code, len(server.Messages()), step.wantCode, step.wantSent)
+		}
+		if step.wantCode == exitFailure && !strings.Contains(stdout, "duplicate") {
+			t.Errorf("%s printed %q, want the duplicate reported", step.name, stdout)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package cli
+
+import "errors"
+
+var (
+	// Usage errors
+	errUsage = errors.New("invalid usage")
+
+	// Input errors
+	errOpenTemplateFailed = errors.New("failed to open template file")
+	errOpenDataFailed     = errors.New("failed to open data file")
+	errNoDataFile         = errors.New("no data file: pass --data or set data in the template front matter")
+
+	// Validation errors
+	errValidationFailed = errors.New("template validation found problems")
+
+	// Login errors
+	errNoTokenCache = errors.New("no token cache location: the user cache directory is unknown")
+	errLoginFailed  = errors.New("login failed")
+
+	// Send errors
+	errNoToken         = errors.New("no access token: run cli login, pass --token or set " + tokenEnv)
+	errMissingTarget   = errors.New("recipients without a message target")
+	errSendFailed      = errors.New("some messages failed to send")
+	errNoJournalDir    = errors.New("cannot resume: the user cache directory is unknown")
+	errJournalFailed   = errors.New("cannot record delivery progress")
+	errDuplicates      = errors.New("some messages were not sent again")
+	errUnknownDelivery = errors.New("some messages may or may not have been sent")
+
+	// Schedule errors
+	errNoScheduleDir   = errors.New("cannot schedule: the user cache directory is unknown")
+	errAtAndIn         = errors.New("--at and --in cannot be combined")
+	errNegativeDelay   = errors.New("--in must be positive")
+	errScheduleResume  = errors.New("--resume cannot be combined with --at or --in")
+	errScheduledFailed = errors.New("some scheduled sends failed")
+)
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+// inputs holds the opened template and data files of a command
+type inputs struct {
+	template *os.File
+	data     *os.File
+	parser   templates.Parser
+}
+
+// openInputs opens the template and data files and picks the data parser by extension.
+// If dataPath is empty, the data file named in the template front matter is used.
+// The caller must call close on the returned inputs.
+func openInputs(templatePath, dataPath string) (*inputs, error) {
+	templateFile, err := os.Open(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errOpenTemplateFailed, err)
+	}
+
+	if dataPath == "" {
+		if dataPath, err = frontMatterDataPath(templateFile); err != nil {
+			closeFile(templateFile)
+			return nil, err
+		}
+	}
+
+	parser, err := templates.NewParserRegistry().GetParser(dataPath)
+	if err != nil {
+		closeFile(templateFile)
+		return nil, err
+	}
+
+	dataFile, err := os.Open(dataPath)
+	if err != nil {
+		closeFile(templateFile)
+		return nil, fmt.Errorf("%w: %w", errOpenDataFailed, err)
+	}
+
+	return &inputs{template: templateFile, data: dataFile, parser: parser}, nil
+}
+
+// frontMatterDataPath returns the data file named in the front matter of templateFile,
+// resolved relative to the template's directory, and rewinds templateFile
+func frontMatterDataPath(templateFile *os.File) (string, error) {
+	meta, err := templates.ReadMetadata(templateFile)
+	if err != nil {
+		return "", err
+	}
+	if _, err := templateFile.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("%w: %w", errOpenTemplateFailed, err)
+	}
+	if meta.Data == "" {
+		return "", errNoDataFile
+	}
+	if filepath.IsAbs(meta.Data) {
+		return meta.Data, nil
+	}
+	return filepath.Join(filepath.Dir(templateFile.Name()), meta.Data), nil
+}
+
+func (in *inputs) close() {
+	closeFile(in.template)
+	closeFile(in.data)
+}
+
+func closeFile(file *os.File) {
+	if err := file.Close(); err != nil {
+		initializers.Logger.Warn("Failed to close file", "file", file.Name(), "error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/pzsp-teams/cli/internal/auth"
+)
+
+// Environment variables read by the login command
+const (
+	authorityEnv = "TEAMS_AUTHORITY"
+	clientIDEnv  = "TEAMS_CLIENT_ID"
+)
+
+func (a *App) runLogin(args []string) error {
+	fs := a.newFlagSet("login")
+	authority := fs.String("authority", "", "identity platform URL including the tenant (default: $"+authorityEnv+" or "+auth.DefaultAuthority+")")
+	clientID := fs.String("client-id", "", "application (client) ID to sign in with (default: $"+clientIDEnv+" or "+auth.DefaultClientID+")")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli login [--authority <url>] [--client-id <id>]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Signs in with a code entered in a browser and caches the tokens used by send.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if a.TokenCache == nil {
+		return errNoTokenCache
+	}
+
+	client := auth.NewClient(auth.Config{
+		Authority: firstNonEmpty(*authority, a.Getenv(authorityEnv)),
+		ClientID:  firstNonEmpty(*clientID, a.Getenv(clientIDEnv)),
+	}, nil)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	token, err := client.Login(ctx, func(code auth.DeviceCode) {
+		fmt.Fprintln(a.Stderr, code.Message)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errLoginFailed, err)
+	}
+	if err := a.TokenCache.Save(auth.NewSession(client.Config(), token)); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.Stdout, "Logged in")
+	return nil
+}
+
+func (a *App) runLogout(args []string) error {
+	fs := a.newFlagSet("logout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli logout")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Removes the cached login. Tokens already issued stay valid until they expire.")
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if a.TokenCache == nil {
+		return errNoTokenCache
+	}
+	if err := a.TokenCache.Clear(); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.Stdout, "Logged out")
+	return nil
+}
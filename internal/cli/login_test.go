@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/auth"
+	"github.com/pzsp-teams/cli/internal/auth/authtest"
+)
+
+func TestLogin_SendLogout(t *testing.T) {
+	authServer := authtest.NewServer(t)
+	graph := newTestServer(t)
+	graph.Token = "access-1"
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\ntarget: channel\ndata: data.json\n---\nHi")
+	writeFile(t, dir, "data.json", `{"Engineering/General": {}}`)
+	cache := &auth.FileCache{Path: filepath.Join(dir, "token.enc"), KeyPath: filepath.Join(dir, "token.key")}
+
+	app, stdout, stderr := newTestApp()
+	app.TokenCache = cache
+	if code := app.Run([]string{"login", "--authority", authServer.Authority}); code != exitOK {
+		t.Fatalf("login exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if !strings.Contains(stderr.String(), "ABCD-EFGH") || !strings.Contains(stdout.String(), "Logged in") {
+		t.Errorf("login output = %q, %q, want the device code and a confirmation", stderr, stdout)
+	}
+
+	app, _, stderr = newTestApp()
+	app.TokenCache = cache
+	if code := app.Run([]string{"send", "--template", tmpl, "--graph-url", graph.URL}); code != exitOK {
+		t.Fatalf("send exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if len(graph.Messages()) != 1 {
+		t.Errorf("server received %d messages, want 1", len(graph.Messages()))
+	}
+
+	app, _, _ = newTestApp()
+	app.TokenCache = cache
+	if code := app.Run([]string{"logout"}); code != exitOK {
+		t.Fatalf("logout exit code = %d, want %d", code, exitOK)
+	}
+	app, _, stderr = newTestApp()
+	app.TokenCache = cache
+	if code := app.Run([]string{"send", "--template", tmpl, "--graph-url", graph.URL}); code != exitFailure {
+		t.Errorf("send after logout exit code = %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(stderr.String(), errNoToken.Error()) {
+		t.Errorf("send after logout stderr = %q, want %q", stderr, errNoToken)
+	}
+}
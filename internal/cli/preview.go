@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/pzsp-teams/cli/internal/preview"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+const defaultPreviewAddr = "127.0.0.1:8080"
+
+func (a *App) runPreview(args []string) error {
+	fs := a.newFlagSet("preview")
+	templatePath := fs.String("template", "", "path to the message template (required)")
+	dataPath := fs.String("data", "", "path to the recipient data file (default: data from the template front matter)")
+	addr := fs.String("addr", defaultPreviewAddr, "address to serve the preview on")
+	format := fs.String("format", "", "content format: auto, plain, html or markdown (default: from the template front matter, else auto)")
+	tmplFlags := addTemplateFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli preview --template <file> [--data <file>] [--addr <host:port>] [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Serves every recipient's rendered message as a web page styled like a Teams post.")
+		fmt.Fprintln(fs.Output(), "Pages reload when the template, data or partials change.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "template"); err != nil {
+		return err
+	}
+
+	opts, err := templateOptions(*format, tmplFlags)
+	if err != nil {
+		return err
+	}
+	// keep rendering the other recipients when one fails, so the page shows what works
+	opts = append(opts, templates.WithCollectErrors())
+
+	// resolve the data file once to know which files to watch
+	in, err := openInputs(*templatePath, *dataPath)
+	if err != nil {
+		return err
+	}
+	watched := []string{*templatePath, in.data.Name()}
+	in.close()
+	if tmplFlags.partials != "" {
+		watched = append(watched, tmplFlags.partials)
+	}
+
+	server := preview.NewServer(func() ([]preview.Page, error) {
+		return renderPages(*templatePath, *dataPath, opts)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return server.Serve(ctx, *addr, watched, func(url string) {
+		fmt.Fprintf(a.Stdout, "Previewing messages at %s (press Ctrl+C to stop)\n", url)
+	})
+}
+
+// renderPages renders the template for every recipient into preview pages.
+// Pages of the recipients that rendered are returned together with any error.
+func renderPages(templatePath, dataPath string, opts []templates.Option) ([]preview.Page, error) {
+	in, err := openInputs(templatePath, dataPath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.close()
+
+	parser, err := templates.NewMessageParser(in.template, in.data, in.parser, opts...)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := parser.Parse()
+
+	pages := make([]preview.Page, len(messages))
+	for i, message := range messages {
+		pages[i] = preview.Page{
+			Recipient:  message.Recipient,
+			Subject:    message.Subject,
+			Summary:    message.Summary,
+			Importance: parser.Metadata().Importance,
+			Body:       message.Body,
+		}
+		if message.Target != nil {
+			pages[i].Target = message.Target.String()
+		}
+	}
+	return pages, err
+}
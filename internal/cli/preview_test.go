@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+func TestRenderPages(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\nsubject: \"For {{.name}}\"\nimportance: urgent\n---\nHello {{.name}} {{.room}}")
+	data := writeFile(t, dir, "data.json", `{
+		"alice": {"name": "Alice", "room": "A1", "_target": "user:alice@example.com"},
+		"bob": {"name": "Bob"}
+	}`)
+
+	pages, err := renderPages(tmpl, data, []templates.Option{templates.WithCollectErrors()})
+
+	if err == nil || !strings.Contains(err.Error(), "bob") {
+		t.Errorf("renderPages() error = %v, want bob's missing room reported", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("renderPages() returned %d pages, want 1", len(pages))
+	}
+	page := pages[0]
+	if page.Recipient != "alice" || page.Target != "user:alice@example.com" || page.Subject != "For Alice" ||
+		page.Importance != "urgent" || page.Body != "Hello Alice A1" {
+		t.Errorf("renderPages() page = %+v", page)
+	}
+}
+
+func TestPreview_Usage(t *testing.T) {
+	app, _, _ := newTestApp()
+
+	if code := app.Run([]string{"preview"}); code != exitUsage {
+		t.Errorf("preview exit code = %d, want %d", code, exitUsage)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pzsp-teams/cli/internal/delivery"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/journal"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+// resumeRun returns the messages of the run with ID runID that still have to be sent,
+// and result rows for the recipients the run already delivered to. Only messages that
+// were certainly not posted are sent again. Recipients whose send was never recorded as
+// finished, or failed after the message may have been posted, may have received it,
+// so they are reported as unknown and not sent again unless force is set.
+// An empty runID starts a new run, so every message is returned.
+func (a *App) resumeRun(runID string, force bool, messages []templates.Message, meta templates.Metadata) ([]templates.Message, []sendResult, error) {
+	if runID == "" {
+		return messages, nil, nil
+	}
+	if a.JournalDir == "" {
+		return nil, nil, errNoJournalDir
+	}
+	entries, err := journal.Load(a.JournalDir, runID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending []templates.Message
+	var skipped []sendResult
+	for _, message := range messages {
+		entry, ok := entries[message.Recipient]
+		target := message.Target.String()
+		if ok && !force && (entry.Status == journal.StatusPending || entry.Status == journal.StatusUnknown) {
+			initializers.Logger.Warn("Recipient may have received the message already, not sending it again",
+				"recipient", message.Recipient, "run", runID, "status", entry.Status)
+			skipped = append(skipped, unknownResult(message.Recipient, target, entry))
+			continue
+		}
+		if !ok || entry.Status != journal.StatusSent {
+			pending = append(pending, message)
+			continue
+		}
+
+		if entry.Hash != journal.Hash(target, delivery.TeamsMessage(message, meta)) {
+			initializers.Logger.Warn("Message changed since it was sent, not sending it again",
+				"recipient", message.Recipient, "run", runID)
+		}
+		skipped = append(skipped, sendResult{
+			recipient: message.Recipient,
+			target:    target,
+			status:    statusSkipped,
+			detail:    entry.MessageID,
+		})
+	}
+	initializers.Logger.Info("Resuming run", "run", runID, "pending", len(pending), "skipped", len(skipped))
+	return pending, skipped, nil
+}
+
+// unknownResult returns the result row of a recipient the run may have delivered to
+func unknownResult(recipient, target string, entry journal.Entry) sendResult {
+	detail := "may have been sent before the run stopped"
+	if entry.Status == journal.StatusUnknown {
+		detail = "may have been sent: " + entry.Error
+	}
+	return sendResult{recipient: recipient, target: target, status: statusUnknown, detail: detail}
+}
+
+// openJournal opens the journal of the run with ID runID, or of a new run if runID is empty.
+// It returns nil if the app has no journal directory.
+func (a *App) openJournal(runID string) (*journal.Journal, error) {
+	if a.JournalDir == "" {
+		return nil, nil
+	}
+	if runID == "" {
+		runID = journal.NewRunID()
+	}
+	j, err := journal.Open(a.JournalDir, runID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errJournalFailed, err)
+	}
+	return j, nil
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/journal"
+)
+
+var runIDRegex = regexp.MustCompile(`--resume (\S+)\)`)
+
+func TestSend_Resume(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail(1, http.StatusForbidden, "Forbidden", 0)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{
+		"first": {"name": "A", "_target": "chat:19:project@thread.v2"},
+		"second": {"name": "B", "_target": "chat:19:project@thread.v2"}
+	}`)
+	args := []string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL, "--concurrency", "1"}
+	journalDir := t.TempDir()
+
+	app, _, stderr := newTestApp()
+	app.JournalDir = journalDir
+	if code := app.Run(args); code != exitFailure {
+		t.Fatalf("send exit code = %d, want %d", code, exitFailure)
+	}
+	match := runIDRegex.FindStringSubmatch(stderr.String())
+	if match == nil {
+		t.Fatalf("send stderr = %q, want the run ID", stderr.String())
+	}
+
+	app, stdout, stderr := newTestApp()
+	app.JournalDir = journalDir
+	if code := app.Run(append(args, "--resume", match[1])); code != exitOK {
+		t.Fatalf("resumed send exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	messages := server.Messages()
+	if len(messages) != 2 || messages[0].Content == messages[1].Content {
+		t.Errorf("server received %+v, want each recipient's message once", messages)
+	}
+	for _, want := range []string{"skipped", "Sent 1 of 1 messages, 1 already sent earlier"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("resumed send stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+}
+
+func TestSend_ResumeAfterBrokenConnection(t *testing.T) {
+	server := newTestServer(t)
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forward := httputil.NewSingleHostReverseProxy(target)
+	var broken atomic.Bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || broken.Swap(true) {
+			forward.ServeHTTP(w, r)
+			return
+		}
+		// the first message is posted, but the connection breaks before the reply
+		forward.ServeHTTP(httptest.NewRecorder(), r)
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			_ = conn.Close()
+		}
+	}))
+	t.Cleanup(proxy.Close)
+
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{
+		"first": {"name": "A", "_target": "chat:19:project@thread.v2"},
+		"second": {"name": "B", "_target": "chat:19:project@thread.v2"}
+	}`)
+	args := []string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", proxy.URL, "--concurrency", "1"}
+	journalDir := t.TempDir()
+
+	app, stdout, stderr := newTestApp()
+	app.JournalDir = journalDir
+	if code := app.Run(args); code != exitFailure || !strings.Contains(stdout.String(), "first      chat:19:project@thread.v2  unknown") {
+		t.Fatalf("send = %d, %q, want the broken send reported unknown", code, stdout.String())
+	}
+	match := runIDRegex.FindStringSubmatch(stderr.String())
+	if match == nil {
+		t.Fatalf("send stderr = %q, want the run ID", stderr.String())
+	}
+
+	app, stdout, _ = newTestApp()
+	app.JournalDir = journalDir
+	if code := app.Run(append(args, "--resume", match[1])); code != exitFailure || !strings.Contains(stdout.String(), "unknown") {
+		t.Errorf("resumed send = %d, %q, want the broken send reported unknown", code, stdout.String())
+	}
+	if len(server.Messages()) != 2 {
+		t.Errorf("server received %d messages, want each recipient's message once", len(server.Messages()))
+	}
+}
+
+func TestSend_ResumeErrors(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi")
+	data := writeFile(t, dir, "data.json", `{"project": {"_target": "chat:19:project@thread.v2"}}`)
+	args := []string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--resume", "20260101-000000-abcdef"}
+
+	app, _, stderr := newTestApp()
+	if code := app.Run(args); code != exitFailure || !strings.Contains(stderr.String(), errNoJournalDir.Error()) {
+		t.Errorf("send without journal directory = %d, %q, want %q", code, stderr.String(), errNoJournalDir)
+	}
+
+	app, _, stderr = newTestApp()
+	app.JournalDir = t.TempDir()
+	if code := app.Run(args); code != exitFailure || !strings.Contains(stderr.String(), "no journal for run") {
+		t.Errorf("send with an unknown run = %d, %q, want the run reported unknown", code, stderr.String())
+	}
+}
+
+func TestSend_ResumePendingIsUnknown(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{"first": {"name": "A", "_target": "chat:19:project@thread.v2"}}`)
+	journalDir := t.TempDir()
+	j, err := journal.Open(journalDir, "run-1")
+	if err != nil {
+		t.Fatalf("journal.Open() unexpected error: %v", err)
+	}
+	if err := j.Record(journal.Entry{Recipient: "first", Status: journal.StatusPending}); err != nil {
+		t.Fatalf("Record() unexpected error: %v", err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		extra        []string
+		wantCode     int
+		wantMessages int
+		wantOutput   string
+	}{
+		{name: "skipped", wantCode: exitFailure, wantMessages: 0, wantOutput: "unknown"},
+		{name: "forced", extra: []string{"--force"}, wantCode: exitOK, wantMessages: 1, wantOutput: "Sent 1 of 1 messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			app, stdout, stderr := newTestApp()
+			app.JournalDir = journalDir
+			args := []string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL, "--resume", "run-1"}
+
+			code := app.Run(append(args, tt.extra...))
+
+			if code != tt.wantCode {
+				t.Errorf("send exit code = %d, want %d; stderr: %s", code, tt.wantCode, stderr)
+			}
+			if got := len(server.Messages()); got != tt.wantMessages {
+				t.Errorf("server received %d messages, want %d", got, tt.wantMessages)
+			}
+			if !strings.Contains(stdout.String(), tt.wantOutput) {
+				t.Errorf("send stdout = %q, want it to contain %q", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
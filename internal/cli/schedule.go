@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/delivery"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/journal"
+	"github.com/pzsp-teams/cli/internal/recipients"
+	"github.com/pzsp-teams/cli/internal/schedule"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+// defaultScheduleInterval is how often the daemon looks for new scheduled sends
+const defaultScheduleInterval = time.Minute
+
+func (a *App) runSchedule(args []string) error {
+	subcommands := []command{
+		{name: "list", summary: "List scheduled sends", run: a.runScheduleList},
+		{name: "cancel", summary: "Cancel scheduled sends by ID", run: a.runScheduleCancel},
+		{name: "reset", summary: "Schedule sends left sending by a crashed dispatcher again", run: a.runScheduleReset},
+		{name: "run", summary: "Send the scheduled sends that are due, or keep sending them with --daemon", run: a.runScheduleRun},
+	}
+	if len(args) > 0 {
+		for _, sub := range subcommands {
+			if sub.name == args[0] {
+				return sub.run(args[1:])
+			}
+		}
+		fmt.Fprintf(a.Stderr, "Unknown schedule command %q\n\n", args[0])
+	}
+
+	fmt.Fprintln(a.Stderr, "Usage: cli schedule <command> [flags]")
+	fmt.Fprintln(a.Stderr)
+	fmt.Fprintln(a.Stderr, "Commands:")
+	for _, sub := range subcommands {
+		fmt.Fprintf(a.Stderr, "  %-10s %s\n", sub.name, sub.summary)
+	}
+	fmt.Fprintln(a.Stderr)
+	fmt.Fprintln(a.Stderr, `Sends are scheduled with "cli send --at <time>" or "cli send --in <duration>".`)
+	return fmt.Errorf("%w: missing schedule command", errUsage)
+}
+
+func (a *App) runScheduleList(args []string) error {
+	fs := a.newFlagSet("schedule list")
+	all := fs.Bool("all", false, "include sends that were sent, failed or cancelled")
+	tz := fs.String("tz", "", "IANA time zone to show times in (default: the system zone)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli schedule list [--all] [--tz <zone>]")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	loc, err := schedule.LoadLocation(*tz)
+	if err != nil {
+		return err
+	}
+	store, err := a.scheduleStore()
+	if err != nil {
+		return err
+	}
+	items, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	var shown []schedule.Item
+	for _, item := range items {
+		if *all || item.Status == schedule.StatusScheduled || item.Status == schedule.StatusSending {
+			shown = append(shown, item)
+		}
+	}
+	printScheduleItems(a.Stdout, shown, loc)
+	return nil
+}
+
+func (a *App) runScheduleCancel(args []string) error {
+	return a.updateScheduled(args, "cancel", "Cancelled", (*schedule.Store).Cancel,
+		"Cancels sends that have not been dispatched yet, or that were left sending by a crashed dispatcher.")
+}
+
+func (a *App) runScheduleReset(args []string) error {
+	return a.updateScheduled(args, "reset", "Reset", (*schedule.Store).Reset,
+		"Schedules sends left sending by a crashed dispatcher again. The next run resumes them from\n"+
+			"their journal: messages already sent are not sent again, and messages that may have been\n"+
+			"sent are reported instead.")
+}
+
+// updateScheduled applies update to the scheduled sends whose IDs are given in args
+func (a *App) updateScheduled(args []string, name, done string, update func(*schedule.Store, string) error, help string) error {
+	fs := a.newFlagSet("schedule " + name)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cli schedule %s <id>...\n", name)
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), help)
+	}
+	if err := parseFlagsWithArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(fs.Output(), "missing schedule IDs")
+		fs.Usage()
+		return fmt.Errorf("%w: missing schedule IDs", errUsage)
+	}
+	store, err := a.scheduleStore()
+	if err != nil {
+		return err
+	}
+	for _, id := range fs.Args() {
+		if err := update(store, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Stdout, "%s %s\n", done, id)
+	}
+	return nil
+}
+
+func (a *App) runScheduleRun(args []string) error {
+	fs := a.newFlagSet("schedule run")
+	flags := addDeliveryFlags(fs)
+	daemon := fs.Bool("daemon", false, "keep running in the foreground, sending scheduled sends as they fall due")
+	interval := fs.Duration("interval", defaultScheduleInterval, "with --daemon, how often to look for new scheduled sends")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli schedule run [--daemon] [--token <token>] [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Sends the scheduled sends that are due. With --daemon, keeps running until interrupted.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	policy, err := flags.retryPolicy()
+	if err != nil {
+		return err
+	}
+	store, err := a.scheduleStore()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	send := func(ctx context.Context, item schedule.Item) (string, string) {
+		status, detail := a.sendScheduled(ctx, flags, policy, item)
+		if status == schedule.StatusFailed {
+			failed++
+		}
+		return status, detail
+	}
+	dispatcher := schedule.NewDispatcher(store, a.clock(), send)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *daemon {
+		fmt.Fprintln(a.Stderr, "Waiting for scheduled sends, press Ctrl+C to stop")
+		return dispatcher.Run(ctx, max(*interval, time.Second))
+	}
+
+	n, err := dispatcher.RunDue(ctx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Fprintln(a.Stdout, "No scheduled sends are due")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", errScheduledFailed, failed, n)
+	}
+	return nil
+}
+
+// sendScheduled sends the messages of a scheduled item, printing their results,
+// and returns the status the item ends in
+func (a *App) sendScheduled(ctx context.Context, flags *deliveryFlags, policy delivery.RetryPolicy, item schedule.Item) (string, string) {
+	fmt.Fprintf(a.Stdout, "Scheduled send %s of %s\n", item.ID, item.Template)
+	messages, err := scheduledMessages(item)
+	if err != nil {
+		fmt.Fprintf(a.Stderr, "Error: %v\n", err)
+		return schedule.StatusFailed, err.Error()
+	}
+
+	meta := templates.Metadata{Importance: item.Importance}
+	messages, skipped, err := a.resumeScheduled(item, messages, meta)
+	if err != nil {
+		fmt.Fprintf(a.Stderr, "Error: %v\n", err)
+		return schedule.StatusFailed, err.Error()
+	}
+	results, err := a.deliverRun(ctx, flags, policy, item.ID, item.Force, messages, meta)
+	if err != nil {
+		fmt.Fprintf(a.Stderr, "Error: %v\n", err)
+		return schedule.StatusFailed, err.Error()
+	}
+	results = append(skipped, results...)
+	printSendResults(a.Stdout, results)
+	if err := sendOutcome(results, flags.dedupWindow); err != nil {
+		return schedule.StatusFailed, err.Error()
+	}
+	return schedule.StatusSent, fmt.Sprintf("%d messages sent", len(results))
+}
+
+// resumeScheduled leaves out the messages an interrupted earlier dispatch of item
+// recorded in its journal, as a send resumed with --resume would
+func (a *App) resumeScheduled(item schedule.Item, messages []templates.Message, meta templates.Metadata) ([]templates.Message, []sendResult, error) {
+	if item.Journal == "" {
+		return messages, nil, nil
+	}
+	if _, err := os.Stat(item.Journal); errors.Is(err, os.ErrNotExist) {
+		return messages, nil, nil
+	}
+	return a.resumeRun(item.ID, item.Force, messages, meta)
+}
+
+// dueTime returns the time a send is scheduled for by --at or --in,
+// or the zero time if it is to be sent now
+func (a *App) dueTime(at string, in time.Duration, tz string) (time.Time, error) {
+	switch {
+	case at != "" && in != 0:
+		return time.Time{}, errAtAndIn
+	case in < 0:
+		return time.Time{}, errNegativeDelay
+	case in > 0:
+		return a.clock().Now().Add(in), nil
+	case at == "":
+		return time.Time{}, nil
+	}
+	loc, err := schedule.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.ParseTime(at, a.clock().Now(), loc)
+}
+
+// scheduleSend queues rendered messages to be sent at dueAt
+func (a *App) scheduleSend(templatePath string, dueAt time.Time, force bool, messages []templates.Message, meta templates.Metadata) error {
+	store, err := a.scheduleStore()
+	if err != nil {
+		return err
+	}
+	item := schedule.Item{
+		// the ID doubles as the run ID of the send's journal
+		ID:         journal.NewRunID(),
+		CreatedAt:  a.clock().Now(),
+		DueAt:      dueAt,
+		Status:     schedule.StatusScheduled,
+		Template:   templatePath,
+		Importance: meta.Importance,
+		Force:      force,
+	}
+	if a.JournalDir != "" {
+		if item.Journal, err = journal.Path(a.JournalDir, item.ID); err != nil {
+			return err
+		}
+	}
+	for _, message := range messages {
+		item.Messages = append(item.Messages, schedule.Message{
+			Recipient: message.Recipient,
+			Target:    message.Target.String(),
+			Subject:   message.Subject,
+			Summary:   message.Summary,
+			Body:      message.Body,
+		})
+	}
+	if err := store.Add(item); err != nil {
+		return err
+	}
+	initializers.Logger.Info("Send scheduled", "id", item.ID, "due_at", dueAt, "messages", len(messages))
+	fmt.Fprintf(a.Stdout, "Scheduled %d messages as %s for %s\n", len(messages), item.ID, dueAt.Format(scheduleTimeLayout))
+	return nil
+}
+
+// scheduledMessages returns the messages of a scheduled item as rendered messages
+func scheduledMessages(item schedule.Item) ([]templates.Message, error) {
+	messages := make([]templates.Message, len(item.Messages))
+	for i, m := range item.Messages {
+		target, err := recipients.ParseTarget(m.Target)
+		if err != nil {
+			return nil, err
+		}
+		messages[i] = templates.Message{
+			Recipient: m.Recipient,
+			Target:    &target,
+			Subject:   m.Subject,
+			Summary:   m.Summary,
+			Body:      m.Body,
+		}
+	}
+	return messages, nil
+}
+
+// scheduleTimeLayout shows the zone so times are unambiguous
+const scheduleTimeLayout = "2006-01-02 15:04 MST"
+
+func printScheduleItems(w io.Writer, items []schedule.Item, loc *time.Location) {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "No scheduled sends")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDUE\tSTATUS\tMESSAGES\tTEMPLATE\tDETAIL")
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", item.ID, item.DueAt.In(loc).Format(scheduleTimeLayout),
+			item.Status, len(item.Messages), item.Template, item.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		initializers.Logger.Warn("Failed to write scheduled sends", "error", err)
+	}
+}
+
+func (a *App) scheduleStore() (*schedule.Store, error) {
+	if a.ScheduleDir == "" {
+		return nil, errNoScheduleDir
+	}
+	return schedule.NewStore(a.ScheduleDir), nil
+}
+
+func (a *App) clock() schedule.Clock {
+	if a.Clock == nil {
+		return schedule.SystemClock{}
+	}
+	return a.Clock
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/journal"
+	"github.com/pzsp-teams/cli/internal/schedule"
+	"github.com/pzsp-teams/cli/internal/schedule/scheduletest"
+)
+
+var scheduleIDRegex = regexp.MustCompile(`as (\S+) for`)
+
+func TestSchedule_SendLater(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\nsubject: Reminder\n---\nHi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{
+		"general": {"name": "all", "_target": "channel:Engineering/General"},
+		"project": {"name": "team", "_target": "chat:19:project@thread.v2"}
+	}`)
+	clock := scheduletest.NewClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	scheduleDir := t.TempDir()
+	run := func(args ...string) (int, string, string) {
+		app, stdout, stderr := newTestApp()
+		app.Clock, app.ScheduleDir = clock, scheduleDir
+		return app.Run(args), stdout.String(), stderr.String()
+	}
+
+	code, stdout, stderr := run("send", "--template", tmpl, "--data", data, "--in", "1h")
+	if code != exitOK {
+		t.Fatalf("send --in exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	match := scheduleIDRegex.FindStringSubmatch(stdout)
+	if match == nil || !strings.Contains(stdout, "Scheduled 2 messages") {
+		t.Fatalf("send --in stdout = %q, want the schedule ID", stdout)
+	}
+	id := match[1]
+
+	// the steps run in order against the same schedule
+	runArgs := []string{"schedule", "run", "--token", "secret", "--graph-url", server.URL}
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		args       []string
+		wantStdout string
+		wantSent   int
+	}{
+		{"list pending", 0, []string{"schedule", "list", "--tz", "Europe/Warsaw"}, id + "  2026-10-18 15:00 CEST  scheduled  2", 0},
+		{"run early", 0, runArgs, "No scheduled sends are due", 0},
+		{"run due", time.Hour, runArgs, "Sent 2 of 2 messages", 2},
+		{"list after send", 0, []string{"schedule", "list"}, "No scheduled sends", 2},
+		{"list all", 0, []string{"schedule", "list", "--all"}, "sent", 2},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			clock.Advance(step.advance)
+			code, stdout, stderr := run(step.args...)
+			if code != exitOK || !strings.Contains(stdout, step.wantStdout) {
+				t.Errorf("exit code = %d, stdout = %q, want %d and %q; stderr: %s", code, stdout, exitOK, step.wantStdout, stderr)
+			}
+			if len(server.Messages()) != step.wantSent {
+				t.Errorf("server received %d messages, want %d", len(server.Messages()), step.wantSent)
+			}
+		})
+	}
+
+	for _, message := range server.Messages() {
+		if message.ChannelID != "" && message.Subject != "Reminder" {
+			t.Errorf("channel message = %+v, want the subject kept", message)
+		}
+	}
+}
+
+func TestSchedule_Cancel(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi")
+	data := writeFile(t, dir, "data.json", `{"project": {"_target": "chat:19:project@thread.v2"}}`)
+	clock := scheduletest.NewClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	app, stdout, stderr := newTestApp()
+	app.Clock, app.ScheduleDir = clock, t.TempDir()
+
+	if code := app.Run([]string{"send", "--template", tmpl, "--data", data, "--at", "2026-10-19 09:00", "--tz", "Europe/Warsaw"}); code != exitOK {
+		t.Fatalf("send --at exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if !strings.Contains(stdout.String(), "for 2026-10-19 09:00 CEST") {
+		t.Errorf("send --at stdout = %q, want the due time in the given zone", stdout.String())
+	}
+	id := scheduleIDRegex.FindStringSubmatch(stdout.String())[1]
+
+	if code := app.Run([]string{"schedule", "cancel", id}); code != exitOK {
+		t.Fatalf("schedule cancel exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if code := app.Run([]string{"schedule", "cancel", id}); code != exitFailure {
+		t.Errorf("second schedule cancel exit code = %d, want %d", code, exitFailure)
+	}
+	clock.Advance(48 * time.Hour)
+	stdout.Reset()
+	if code := app.Run([]string{"schedule", "run", "--token", "secret"}); code != exitOK || !strings.Contains(stdout.String(), "No scheduled sends are due") {
+		t.Errorf("schedule run = %d, %q, want the cancelled send skipped", code, stdout.String())
+	}
+}
+
+func TestSchedule_ResetCrashed(t *testing.T) {
+	server := newTestServer(t)
+	server.AddChat("19:third@thread.v2", "Third", "group")
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi")
+	data := writeFile(t, dir, "data.json", `{
+		"first": {"_target": "chat:19:first@thread.v2"},
+		"second": {"_target": "chat:19:second@thread.v2"},
+		"third": {"_target": "chat:19:third@thread.v2"}
+	}`)
+	clock := scheduletest.NewClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	scheduleDir, journalDir := t.TempDir(), t.TempDir()
+	run := func(args ...string) (int, string, string) {
+		app, stdout, stderr := newTestApp()
+		app.Clock, app.ScheduleDir, app.JournalDir = clock, scheduleDir, journalDir
+		return app.Run(args), stdout.String(), stderr.String()
+	}
+
+	code, stdout, stderr := run("send", "--template", tmpl, "--data", data, "--in", "1h")
+	if code != exitOK {
+		t.Fatalf("send --in exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	id := scheduleIDRegex.FindStringSubmatch(stdout)[1]
+
+	// a dispatcher claims the send and crashes after posting to first, while posting to second
+	claimCrashed(t, scheduleDir, journalDir, id)
+
+	if code, _, stderr := run("schedule", "reset", id); code != exitFailure || !strings.Contains(stderr, "still being dispatched") {
+		t.Errorf("schedule reset while claimed = %d, %q, want the live claim refused", code, stderr)
+	}
+	if err := os.Remove(filepath.Join(scheduleDir, id+".sending")); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, stderr := run("schedule", "reset", id); code != exitOK || !strings.Contains(stdout, "Reset "+id) {
+		t.Fatalf("schedule reset = %d, %q, want the crashed send reset; stderr: %s", code, stdout, stderr)
+	}
+
+	clock.Advance(time.Hour)
+	code, stdout, _ = run("schedule", "run", "--token", "secret", "--graph-url", server.URL)
+	if code != exitFailure {
+		t.Errorf("schedule run exit code = %d, want %d as second may have been sent", code, exitFailure)
+	}
+	if len(server.Messages()) != 1 || server.Messages()[0].ChatID != "19:third@thread.v2" {
+		t.Errorf("server received %+v, want only the message to third", server.Messages())
+	}
+	for _, want := range []string{"first      chat:19:first@thread.v2   skipped", "second     chat:19:second@thread.v2  unknown"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("schedule run stdout = %q, want it to contain %q", stdout, want)
+		}
+	}
+}
+
+// claimCrashed claims the scheduled send id and records a journal of a dispatch
+// that posted to first and was interrupted while posting to second
+func claimCrashed(t *testing.T, scheduleDir, journalDir, id string) {
+	t.Helper()
+	item, err := schedule.NewStore(scheduleDir).Claim(id)
+	if err != nil {
+		t.Fatalf("Claim() unexpected error: %v", err)
+	}
+	if want := filepath.Join(journalDir, id+".jsonl"); item.Journal != want {
+		t.Errorf("item journal = %q, want %q", item.Journal, want)
+	}
+	j, err := journal.Open(journalDir, id)
+	if err != nil {
+		t.Fatalf("journal.Open() unexpected error: %v", err)
+	}
+	for _, entry := range []journal.Entry{
+		{Recipient: "first", Status: journal.StatusSent, MessageID: "msg-1"},
+		{Recipient: "second", Status: journal.StatusPending},
+	} {
+		if err := j.Record(entry); err != nil {
+			t.Fatalf("Record() unexpected error: %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+}
+
+func TestSchedule_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi")
+	data := writeFile(t, dir, "data.json", `{"project": {"_target": "chat:19:project@thread.v2"}}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"at and in", []string{"send", "--template", tmpl, "--data", data, "--at", "09:00", "--in", "1h"}, exitFailure, errAtAndIn.Error()},
+		{"past time", []string{"send", "--template", tmpl, "--data", data, "--at", "2020-01-01 09:00"}, exitFailure, "time is in the past"},
+		{"unknown zone", []string{"send", "--template", tmpl, "--data", data, "--at", "09:00", "--tz", "Mars/Olympus"}, exitFailure, "unknown time zone"},
+		{"resume", []string{"send", "--template", tmpl, "--data", data, "--in", "1h", "--resume", "run"}, exitFailure, errScheduleResume.Error()},
+		{"no subcommand", []string{"schedule"}, exitUsage, "Usage: cli schedule"},
+		{"cancel without ID", []string{"schedule", "cancel"}, exitUsage, "missing schedule IDs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, stderr := newTestApp()
+			app.Clock = scheduletest.NewClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+			app.ScheduleDir = t.TempDir()
+
+			if code := app.Run(tt.args); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/auth"
+	"github.com/pzsp-teams/cli/internal/delivery"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/recipients"
+	"github.com/pzsp-teams/cli/internal/teams"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+// Environment variables read by the send command
+const (
+	tokenEnv    = "TEAMS_TOKEN"
+	graphURLEnv = "TEAMS_GRAPH_URL"
+)
+
+// Delivery statuses printed in the send result table
+const (
+	statusSent   = "sent"
+	statusFailed = "failed"
+	// statusSkipped marks recipients a resumed run had already delivered to
+	statusSkipped = "skipped"
+	// statusDuplicate marks messages not sent because they were sent recently
+	statusDuplicate = "duplicate"
+	// statusUnknown marks recipients a failed or interrupted send may or may not have delivered to
+	statusUnknown = "unknown"
+)
+
+// sendResult is the outcome of delivering the message for one recipient
+type sendResult struct {
+	recipient string
+	target    string
+	status    string
+	// detail is the ID of the sent message or the failure reason
+	detail string
+}
+
+// deliveryFlags are the flags controlling how messages are delivered,
+// shared by the commands that send
+type deliveryFlags struct {
+	token         string
+	graphURL      string
+	concurrency   int
+	rate          float64
+	maxAttempts   int
+	retryDelay    time.Duration
+	retryMaxDelay time.Duration
+	retryJitter   float64
+	retryOn       string
+	retryNetwork  bool
+	dedupWindow   time.Duration
+}
+
+func addDeliveryFlags(fs *flag.FlagSet) *deliveryFlags {
+	f := &deliveryFlags{}
+	fs.StringVar(&f.token, "token", "", "Microsoft Graph access token (default: $"+tokenEnv+", else the token of cli login)")
+	fs.StringVar(&f.graphURL, "graph-url", "", "Microsoft Graph base URL (default: $"+graphURLEnv+" or "+teams.DefaultGraphURL+")")
+	fs.IntVar(&f.concurrency, "concurrency", delivery.DefaultConcurrency, "number of messages sent at the same time")
+	fs.Float64Var(&f.rate, "rate", delivery.DefaultRate, "maximum messages sent per second, or 0 for no limit")
+	fs.IntVar(&f.maxAttempts, "max-attempts", delivery.DefaultRetryPolicy.MaxAttempts, "attempts per message before giving up, including the first")
+	fs.DurationVar(&f.retryDelay, "retry-delay", delivery.DefaultRetryPolicy.BaseDelay, "wait before the first retry, doubling with every further retry")
+	fs.DurationVar(&f.retryMaxDelay, "retry-max-delay", delivery.DefaultRetryPolicy.MaxDelay, "longest wait between retries")
+	fs.Float64Var(&f.retryJitter, "retry-jitter", delivery.DefaultRetryPolicy.Jitter, "fraction of each wait that is randomized, from 0 to 1")
+	fs.StringVar(&f.retryOn, "retry-on", statusRanges(delivery.DefaultRetryPolicy.RetryOn),
+		"comma separated HTTP statuses and classes to retry, e.g. 408,5xx. Other server errors, such as 500, 502 and 504, "+
+			"are not retried by default, as the message may have been posted and a retry would post it twice")
+	fs.BoolVar(&f.retryNetwork, "retry-network-errors", false,
+		"also retry timeouts and broken connections, which may post a message twice (failed connections are always retried)")
+	fs.DurationVar(&f.dedupWindow, "dedup-window", defaultDedupWindow, "refuse to send a message sent to the same recipient within this time, or 0 to allow")
+	return f
+}
+
+// retryPolicy returns the retry policy given by the flags
+func (f *deliveryFlags) retryPolicy() (delivery.RetryPolicy, error) {
+	retryStatuses, err := delivery.ParseStatusRanges(f.retryOn)
+	if err != nil {
+		return delivery.RetryPolicy{}, err
+	}
+	policy := delivery.RetryPolicy{
+		MaxAttempts:        f.maxAttempts,
+		BaseDelay:          f.retryDelay,
+		MaxDelay:           f.retryMaxDelay,
+		Jitter:             f.retryJitter,
+		RetryOn:            retryStatuses,
+		RetryNetworkErrors: f.retryNetwork,
+	}
+	return policy, policy.Validate()
+}
+
+func (a *App) runSend(args []string) error {
+	fs := a.newFlagSet("send")
+	templatePath := fs.String("template", "", "path to the message template (required)")
+	dataPath := fs.String("data", "", "path to the recipient data file (default: data from the template front matter)")
+	format := fs.String("format", "", "content format: auto, plain, html or markdown (default: from the template front matter, else auto)")
+	tmplFlags := addTemplateFlags(fs)
+	flags := addDeliveryFlags(fs)
+	force := fs.Bool("force", false, "send messages even if they were sent within the dedup window or, with --resume, may have been sent already")
+	resume := fs.String("resume", "", "ID of an interrupted run to continue, sending only to recipients not yet delivered")
+	at := fs.String("at", "", "schedule the send for a time instead of sending now: RFC 3339, \"YYYY-MM-DD HH:MM\" or \"HH:MM\"")
+	delay := fs.Duration("in", 0, "schedule the send for this long from now instead of sending now")
+	tz := fs.String("tz", "", "IANA time zone of --at times without an offset, e.g. Europe/Warsaw (default: the system zone)")
+	dryRun := fs.Bool("dry-run", false, "print the rendered messages and their targets without sending anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli send --template <file> [--data <file>] [--token <token>] [--at <time> | --in <duration>] [--dry-run] [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Renders the template for every recipient and sends each message to the recipient's target.")
+		fmt.Fprintln(fs.Output(), "With --at or --in the rendered messages are queued and sent by \"cli schedule run\" instead.")
+		fmt.Fprintln(fs.Output(), "Exits non-zero if any message fails to send.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "template"); err != nil {
+		return err
+	}
+
+	opts, err := templateOptions(*format, tmplFlags)
+	if err != nil {
+		return err
+	}
+	policy, err := flags.retryPolicy()
+	if err != nil {
+		return err
+	}
+	dueAt, err := a.dueTime(*at, *delay, *tz)
+	if err != nil {
+		return err
+	}
+	if !dueAt.IsZero() && *resume != "" {
+		return errScheduleResume
+	}
+
+	in, err := openInputs(*templatePath, *dataPath)
+	if err != nil {
+		return err
+	}
+	defer in.close()
+
+	messages, meta, unrendered, err := a.renderMessages(in, opts)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		printDryRun(a.Stdout, messages, meta)
+		return checkTargets(messages)
+	}
+	if err := checkTargets(messages); err != nil {
+		return err
+	}
+	if !dueAt.IsZero() {
+		return a.scheduleSend(*templatePath, dueAt, *force, messages, meta)
+	}
+	messages, skipped, err := a.resumeRun(*resume, *force, messages, meta)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := a.deliverRun(ctx, flags, policy, *resume, *force, messages, meta)
+	if err != nil {
+		return err
+	}
+	results = slices.Concat(unrendered, skipped, results)
+	printSendResults(a.Stdout, results)
+	return sendOutcome(results, flags.dedupWindow)
+}
+
+// deliverRun sends messages as the run with ID runID, or a new run if runID is empty,
+// and returns their result rows. Unless force is set, messages sent within the dedup
+// window are not sent again.
+func (a *App) deliverRun(ctx context.Context, flags *deliveryFlags, policy delivery.RetryPolicy, runID string, force bool,
+	messages []templates.Message, meta templates.Metadata) ([]sendResult, error) {
+	store, err := a.openSentStore(flags.dedupWindow)
+	if err != nil {
+		return nil, err
+	}
+	var duplicates []sendResult
+	if store != nil && !force && flags.dedupWindow > 0 {
+		messages, duplicates = findDuplicates(store, messages, meta, flags.dedupWindow)
+	}
+
+	tokens, err := a.tokenSource(ctx, flags.token)
+	if err != nil {
+		return nil, err
+	}
+	client := teams.NewGraphClient(tokens,
+		teams.WithBaseURL(firstNonEmpty(flags.graphURL, a.Getenv(graphURLEnv), teams.DefaultGraphURL)))
+	engineOpts := []delivery.Option{delivery.WithConcurrency(flags.concurrency),
+		delivery.WithLimiter(delivery.NewLimiter(flags.rate, flags.concurrency)), delivery.WithRetryPolicy(policy)}
+	j, err := a.openJournal(runID)
+	if err != nil {
+		return nil, err
+	}
+	if j != nil {
+		defer func() {
+			if err := j.Close(); err != nil {
+				initializers.Logger.Warn("Failed to close delivery journal", "error", err)
+			}
+		}()
+		fmt.Fprintf(a.Stderr, "Run %s (continue it with --resume %s)\n", j.RunID(), j.RunID())
+		engineOpts = append(engineOpts, delivery.WithJournal(j))
+	}
+	if store != nil {
+		engineOpts = append(engineOpts, delivery.WithSentStore(store))
+	}
+	results := sendResults(delivery.NewEngine(client, engineOpts...).Deliver(ctx, messages, meta))
+	return append(duplicates, results...), nil
+}
+
+// sendOutcome returns the error a send with results ends in, if any
+func sendOutcome(results []sendResult, dedupWindow time.Duration) error {
+	failed, duplicates, unknown := 0, 0, 0
+	for _, result := range results {
+		switch result.status {
+		case statusFailed:
+			failed++
+		case statusDuplicate:
+			duplicates++
+		case statusUnknown:
+			unknown++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", errSendFailed, failed, len(results))
+	}
+	if unknown > 0 {
+		return fmt.Errorf("%w: %d, check whether they arrived and resend them with --force", errUnknownDelivery, unknown)
+	}
+	if duplicates > 0 {
+		return fmt.Errorf("%w: %d sent within the last %s", errDuplicates, duplicates, dedupWindow)
+	}
+	return nil
+}
+
+// tokenSource returns the access token given as a flag or in the environment, or else the
+// tokens of the cached login. The cached login is checked up front so a missing login
+// is reported once rather than for every message.
+func (a *App) tokenSource(ctx context.Context, token string) (teams.TokenSource, error) {
+	if token = firstNonEmpty(token, a.Getenv(tokenEnv)); token != "" {
+		return teams.StaticToken(token), nil
+	}
+	if a.TokenCache == nil {
+		return nil, errNoToken
+	}
+	source := auth.NewTokenSource(a.TokenCache, nil)
+	if _, err := source.Token(ctx); err != nil {
+		if auth.IsNotLoggedIn(err) {
+			return nil, fmt.Errorf("%w (%w)", errNoToken, err)
+		}
+		return nil, err
+	}
+	return source, nil
+}
+
+// renderMessages renders the opened template for every recipient in the opened data.
+// Recipients left out under --missing-key skip are printed as a warning and returned as failed result rows.
+func (a *App) renderMessages(in *inputs, opts []templates.Option) ([]templates.Message, templates.Metadata, []sendResult, error) {
+	parser, err := templates.NewMessageParser(in.template, in.data, in.parser, opts...)
+	if err != nil {
+		return nil, templates.Metadata{}, nil, err
+	}
+	messages, err := parser.Parse()
+	var report *templates.RenderReport
+	if errors.As(err, &report) {
+		// the other messages still go out, as --missing-key skip asks
+		fmt.Fprintf(a.Stderr, "Warning: %v\n", report)
+		return messages, parser.Metadata(), unrenderedResults(report), nil
+	}
+	if err != nil {
+		return nil, templates.Metadata{}, nil, err
+	}
+	return messages, parser.Metadata(), nil, nil
+}
+
+// unrenderedResults returns failed result rows for the recipients whose message could not be rendered
+func unrenderedResults(report *templates.RenderReport) []sendResult {
+	rows := make([]sendResult, len(report.Failures))
+	for i, failure := range report.Failures {
+		rows[i] = sendResult{recipient: failure.Recipient, status: statusFailed, detail: "not rendered: " + failure.Err.Error()}
+	}
+	return rows
+}
+
+// checkTargets reports the recipients whose data does not say where to send their message
+func checkTargets(messages []templates.Message) error {
+	var missing []string
+	for _, message := range messages {
+		if message.Target == nil {
+			missing = append(missing, message.Recipient)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (set %s in the data or target in the template front matter)",
+		errMissingTarget, strings.Join(missing, ", "), templates.TargetKey)
+}
+
+// sendResults converts delivery results into rows of the result table
+func sendResults(results []delivery.Result) []sendResult {
+	rows := make([]sendResult, len(results))
+	for i, result := range results {
+		rows[i] = sendResult{recipient: result.Message.Recipient, target: result.Message.Target.String()}
+		switch {
+		case result.Err != nil && result.MaybeSent:
+			rows[i].status, rows[i].detail = statusUnknown, "may have been sent: "+result.Err.Error()
+		case result.Err != nil:
+			rows[i].status, rows[i].detail = statusFailed, result.Err.Error()
+		default:
+			rows[i].status, rows[i].detail = statusSent, result.Sent.ID
+		}
+	}
+	return rows
+}
+
+// printDryRun prints every message as it would be sent: its target and parts,
+// the body both as terminal text and as the HTML posted to Teams
+func printDryRun(w io.Writer, messages []templates.Message, meta templates.Metadata) {
+	for i, message := range messages {
+		target := "(none)"
+		if message.Target != nil {
+			target = message.Target.String()
+		}
+		body := message.Body
+		if message.Target != nil && message.Target.Kind != recipients.KindChannel {
+			body = teams.ChatBody(message.Subject, body)
+		}
+		fmt.Fprintf(w, "=== Message %d of %d\n", i+1, len(messages))
+		fmt.Fprintf(w, "Recipient:  %s\n", message.Recipient)
+		fmt.Fprintf(w, "Target:     %s\n", target)
+		printField(w, "Subject:   ", message.Subject)
+		printField(w, "Summary:   ", message.Summary)
+		printField(w, "Importance:", meta.Importance)
+		fmt.Fprintln(w, "--- Text")
+		fmt.Fprintln(w, templates.HTMLToText(body))
+		fmt.Fprintln(w, "--- HTML")
+		fmt.Fprintln(w, body)
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Dry run: rendered %d messages, nothing was sent\n", len(messages))
+}
+
+func printField(w io.Writer, label, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "%s %s\n", label, value)
+	}
+}
+
+func printSendResults(w io.Writer, results []sendResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RECIPIENT\tTARGET\tSTATUS\tDETAIL")
+	sent, skipped := 0, 0
+	for _, result := range results {
+		switch result.status {
+		case statusSent:
+			sent++
+		case statusSkipped:
+			skipped++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.recipient, result.target, result.status, result.detail)
+	}
+	if err := tw.Flush(); err != nil {
+		initializers.Logger.Warn("Failed to write results", "error", err)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(w, "\nSent %d of %d messages, %d already sent earlier\n", sent, len(results)-skipped, skipped)
+		return
+	}
+	fmt.Fprintf(w, "\nSent %d of %d messages\n", sent, len(results))
+}
+
+// statusRanges formats ranges as accepted by delivery.ParseStatusRanges
+func statusRanges(ranges []delivery.StatusRange) string {
+	names := make([]string, len(ranges))
+	for i, r := range ranges {
+		names[i] = r.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
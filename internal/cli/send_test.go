@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/teams/teamstest"
+)
+
+// newTestServer returns a Graph test server with a team, a group chat and a user
+func newTestServer(t *testing.T) *teamstest.Server {
+	t.Helper()
+	server := teamstest.NewServer(t)
+	server.Token = "secret"
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "c1", Name: "General"})
+	server.AddChat("19:project@thread.v2", "Project", "group")
+	server.AddUser("user-2", "bob@example.com")
+	return server
+}
+
+func TestSend_DeliversMessages(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\nsubject: \"News for {{.name}}\"\nimportance: high\n---\nHello {{.name}}!")
+	data := writeFile(t, dir, "data.yaml", `general:
+  name: Engineering
+  _target: channel:Engineering/General
+bob:
+  name: Bob
+  _target: user:bob@example.com
+project:
+  name: Project
+  _target: "chat:19:project@thread.v2"
+`)
+	app, stdout, stderr := newTestApp()
+
+	code := app.Run([]string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL})
+
+	if code != exitOK {
+		t.Fatalf("send exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	messages := server.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("server received %d messages, want 3", len(messages))
+	}
+	// messages are sent concurrently, so they may arrive in any order
+	byDestination := map[string]teamstest.PostedMessage{}
+	for _, message := range messages {
+		byDestination[message.ChannelID+message.ChatID] = message
+	}
+	if message := byDestination["c1"]; message.Subject != "News for Engineering" || message.Importance != "high" {
+		t.Errorf("channel message = %+v", message)
+	}
+	if message := byDestination["19:00000000-0000-0000-0000-000000000001_user-2@unq.gbl.spaces"]; message.Content != "<p><b>News for Bob</b></p>Hello Bob!" {
+		t.Errorf("1:1 chat message = %+v", message)
+	}
+	if message := byDestination["19:project@thread.v2"]; message.Content != "<p><b>News for Project</b></p>Hello Project!" {
+		t.Errorf("group chat message = %+v", message)
+	}
+	for _, want := range []string{"RECIPIENT", "general    channel:Engineering/General  sent", "sent", "Sent 3 of 3 messages"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("send stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+}
+
+func TestSend_TokenAndURLFromEnvironment(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\ntarget: channel\ndata: data.json\n---\nHi")
+	writeFile(t, dir, "data.json", `{"Engineering/General": {}}`)
+	app, _, stderr := newTestApp()
+	app.Getenv = func(key string) string {
+		return map[string]string{tokenEnv: "secret", graphURLEnv: server.URL}[key]
+	}
+
+	if code := app.Run([]string{"send", "--template", tmpl}); code != exitOK {
+		t.Fatalf("send exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if len(server.Messages()) != 1 {
+		t.Errorf("server received %d messages, want 1", len(server.Messages()))
+	}
+}
+
+func TestSend_ReportsFailures(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail(1, http.StatusForbidden, "Forbidden", 0)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{
+		"first": {"name": "A", "_target": "chat:19:project@thread.v2"},
+		"second": {"name": "B", "_target": "chat:19:project@thread.v2"},
+		"missing": {"name": "C", "_target": "channel:Engineering/Nope"}
+	}`)
+	app, stdout, stderr := newTestApp()
+
+	// one message at a time, so the injected failure hits the first message
+	code := app.Run([]string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL, "--concurrency", "1"})
+
+	if code != exitFailure {
+		t.Errorf("send exit code = %d, want %d", code, exitFailure)
+	}
+	if len(server.Messages()) != 1 {
+		t.Errorf("server received %d messages, want 1", len(server.Messages()))
+	}
+	for _, want := range []string{"first", "Forbidden", "second", "sent", "no channel named \"Nope\"", "Sent 1 of 3 messages"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("send stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+	if !strings.Contains(stderr.String(), "2 of 3") {
+		t.Errorf("send stderr = %q, want failure count", stderr.String())
+	}
+}
+
+func TestSend_MissingKeyPolicy(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	data := writeFile(t, dir, "data.json", `{
+		"named": {"name": "A", "_target": "chat:19:project@thread.v2"},
+		"unnamed": {"_target": "chat:19:project@thread.v2"}
+	}`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantSent   []string
+		wantStdout string
+	}{
+		{name: "fail", wantCode: exitFailure},
+		{name: "skip", args: []string{"--missing-key", "skip"}, wantCode: exitFailure, wantSent: []string{"Hi A"},
+			wantStdout: "failed  not rendered: "},
+		{name: "default", args: []string{"--missing-key", "default", "--missing-default", "there"}, wantCode: exitOK,
+			wantSent: []string{"Hi A", "Hi there"}, wantStdout: "Sent 2 of 2 messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			app, stdout, _ := newTestApp()
+
+			args := append([]string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL}, tt.args...)
+			if code := app.Run(args); code != tt.wantCode {
+				t.Errorf("send exit code = %d, want %d", code, tt.wantCode)
+			}
+			var sent []string
+			for _, message := range server.Messages() {
+				sent = append(sent, message.Content)
+			}
+			sort.Strings(sent)
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("server received %q, want %q", sent, tt.wantSent)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("send stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestSend_Escaping(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", `<p>Hi {{.name}}, <a href="https://example.com/?q={{.query}}">search</a></p>`)
+	data := writeFile(t, dir, "data.json", `{"project": {"name": "<b>team</b>", "query": "a b", "_target": "chat:19:project@thread.v2"}}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{name: "escaped by default", wantCode: exitOK, want: `<p>Hi &lt;b&gt;team&lt;/b&gt;, <a href="https://example.com/?q=a b">search</a></p>`},
+		{name: "HTML escaping", args: []string{"--html-escaping"}, wantCode: exitOK, want: `<p>Hi &lt;b&gt;team&lt;/b&gt;, <a href="https://example.com/?q=a%20b">search</a></p>`},
+		{name: "raw data", args: []string{"--raw-data"}, wantCode: exitOK, want: `<p>Hi <b>team</b>, <a href="https://example.com/?q=a b">search</a></p>`},
+		{name: "both", args: []string{"--html-escaping", "--raw-data"}, wantCode: exitFailure},
+		{name: "HTML escaping of Markdown", args: []string{"--html-escaping", "--format", "markdown"}, wantCode: exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			app, _, stderr := newTestApp()
+
+			args := append([]string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL}, tt.args...)
+			if code := app.Run(args); code != tt.wantCode {
+				t.Fatalf("send exit code = %d, want %d; stderr: %s", code, tt.wantCode, stderr)
+			}
+			messages := server.Messages()
+			if tt.want == "" {
+				if len(messages) != 0 {
+					t.Errorf("server received %d messages, want none", len(messages))
+				}
+				return
+			}
+			if len(messages) != 1 || messages[0].Content != tt.want {
+				t.Errorf("server received %+v, want one message with %q", messages, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_RetriesServerErrors(t *testing.T) {
+	server := newTestServer(t)
+	server.Fail(2, http.StatusServiceUnavailable, "ServiceUnavailable", 0)
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi")
+	data := writeFile(t, dir, "data.json", `{"project": {"_target": "chat:19:project@thread.v2"}}`)
+	app, _, stderr := newTestApp()
+
+	code := app.Run([]string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL,
+		"--max-attempts", "3", "--retry-delay", "1ms"})
+
+	if code != exitOK {
+		t.Fatalf("send exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if len(server.Messages()) != 1 || server.Requests() != 3 {
+		t.Errorf("server received %d messages in %d requests, want 1 in 3", len(server.Messages()), server.Requests())
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hi {{.name}}")
+	addressed := writeFile(t, dir, "addressed.json", `{"bob": {"name": "Bob", "_target": "user:bob@example.com"}}`)
+	unaddressed := writeFile(t, dir, "unaddressed.json", `{"bob": {"name": "Bob"}}`)
+	incomplete := writeFile(t, dir, "incomplete.json", `{"bob": {"_target": "user:bob@example.com"}}`)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{"no token", []string{"--data", addressed}, errNoToken},
+		{"no target", []string{"--data", unaddressed, "--token", "x"}, errMissingTarget},
+		{"render failure", []string{"--data", incomplete, "--token", "x"}, nil},
+		{"invalid retry status", []string{"--data", addressed, "--token", "x", "--retry-on", "7xx"}, nil},
+		{"invalid retry policy", []string{"--data", addressed, "--token", "x", "--max-attempts", "0"}, nil},
+		{"invalid missing key policy", []string{"--data", addressed, "--token", "x", "--missing-key", "ignore"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			app, _, stderr := newTestApp()
+
+			args := append([]string{"send", "--template", tmpl, "--graph-url", server.URL}, tt.args...)
+			if code := app.Run(args); code != exitFailure {
+				t.Errorf("send exit code = %d, want %d", code, exitFailure)
+			}
+			if tt.wantErr != nil && !strings.Contains(stderr.String(), tt.wantErr.Error()) {
+				t.Errorf("send stderr = %q, want %q", stderr.String(), tt.wantErr)
+			}
+			if server.Requests() != 0 {
+				t.Errorf("send made %d requests, want none", server.Requests())
+			}
+		})
+	}
+}
+
+func TestSend_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\nsubject: \"Hi {{.name}}\"\nformat: markdown\n---\n# Update\n\n- **{{.name}}** & co")
+	data := writeFile(t, dir, "data.json", `{
+		"bob": {"name": "Bob", "_target": "user:bob@example.com"},
+		"carol": {"name": "Carol"}
+	}`)
+	server := newTestServer(t)
+	app, stdout, stderr := newTestApp()
+
+	code := app.Run([]string{"send", "--template", tmpl, "--data", data, "--token", "secret", "--graph-url", server.URL, "--dry-run"})
+
+	if code != exitFailure {
+		t.Errorf("send exit code = %d, want %d for a recipient without target", code, exitFailure)
+	}
+	for _, want := range []string{
+		"=== Message 1 of 2",
+		"Recipient:  bob",
+		"Target:     user:bob@example.com",
+		"Subject:    Hi Bob",
+		"--- Text\nHi Bob\n\nUpdate\n\n- Bob & co\n",
+		"--- HTML\n<p><b>Hi Bob</b></p><h1>Update</h1><ul><li><b>Bob</b> &amp; co</li></ul>\n",
+		"Target:     (none)",
+		"Dry run: rendered 2 messages, nothing was sent",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("send stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+	if !strings.Contains(stderr.String(), errMissingTarget.Error()) {
+		t.Errorf("send stderr = %q, want %q", stderr.String(), errMissingTarget)
+	}
+	if server.Requests() != 0 {
+		t.Errorf("send --dry-run made %d requests, want none", server.Requests())
+	}
+}
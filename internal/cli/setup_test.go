@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	initializers.InitLogger(&logger.Config{
+		Level:  logger.LevelDebug,
+		Format: logger.FormatText,
+		Output: io.Discard,
+	})
+	os.Exit(m.Run())
+}
+
+// newTestApp returns an App writing to buffers with an empty environment
+func newTestApp() (app *App, stdout, stderr *bytes.Buffer) {
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	getenv := func(string) string { return "" }
+	return &App{Stdout: stdout, Stderr: stderr, Getenv: getenv}, stdout, stderr
+}
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
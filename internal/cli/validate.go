@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+func (a *App) runValidate(args []string) error {
+	fs := a.newFlagSet("validate")
+	templatePath := fs.String("template", "", "path to the message template (required)")
+	dataPath := fs.String("data", "", "path to the recipient data file (default: data from the template front matter)")
+	strict := fs.Bool("strict", false, "also fail when data keys are not used by the template")
+	tmplFlags := addTemplateFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cli validate --template <file> [--data <file>] [--strict] [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Statically checks a template against a data file and exits non-zero on problems.")
+		fs.PrintDefaults()
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "template"); err != nil {
+		return err
+	}
+
+	in, err := openInputs(*templatePath, *dataPath)
+	if err != nil {
+		return err
+	}
+	defer in.close()
+
+	opts, err := templateOptions("", tmplFlags)
+	if err != nil {
+		return err
+	}
+	report, err := templates.Lint(in.template, in.data, in.parser, opts...)
+	if err != nil {
+		return err
+	}
+
+	printLintReport(a.Stdout, report)
+	if report.HasProblems() || (*strict && len(report.Unused) > 0) {
+		return errValidationFailed
+	}
+	fmt.Fprintln(a.Stdout, "OK: no problems found")
+	return nil
+}
+
+// templateFlags are the flags controlling how templates are rendered,
+// shared by the commands that render
+type templateFlags struct {
+	partials       string
+	layout         string
+	missingKey     string
+	missingDefault string
+	htmlEscaping   bool
+	rawData        bool
+}
+
+func addTemplateFlags(fs *flag.FlagSet) *templateFlags {
+	f := &templateFlags{}
+	fs.StringVar(&f.partials, "partials", "", "directory of partial and layout templates")
+	fs.StringVar(&f.layout, "layout", "", "name of the layout template to render messages through")
+	fs.StringVar(&f.missingKey, "missing-key", templates.MissingKeyFail.String(),
+		"handling of placeholders missing from a recipient's data: fail, skip the recipient, or default")
+	fs.StringVar(&f.missingDefault, "missing-default", "", "value rendered for missing placeholders with --missing-key default")
+	fs.BoolVar(&f.htmlEscaping, "html-escaping", false,
+		"escape values by where they appear in the HTML: element text, attributes or link URLs (auto and html formats only)")
+	fs.BoolVar(&f.rawData, "raw-data", false, "print data values without HTML escaping, for data that carries markup")
+	return f
+}
+
+// templateOptions returns the template options for the --format flag and the template flags
+func templateOptions(format string, f *templateFlags) ([]templates.Option, error) {
+	missingKey, err := templates.ParseMissingKeyPolicy(f.missingKey)
+	if err != nil {
+		return nil, err
+	}
+	opts := []templates.Option{templates.WithMissingKeyPolicy(missingKey), templates.WithMissingKeyDefault(f.missingDefault)}
+	if format != "" {
+		contentFormat, err := templates.ParseContentFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, templates.WithContentFormat(contentFormat))
+	}
+	if f.partials != "" {
+		opts = append(opts, templates.WithPartials(f.partials))
+	}
+	if f.layout != "" {
+		opts = append(opts, templates.WithLayout(f.layout))
+	}
+	if f.htmlEscaping {
+		opts = append(opts, templates.WithHTMLEscaping())
+	}
+	if f.rawData {
+		opts = append(opts, templates.WithRawData())
+	}
+	return opts, nil
+}
+
+func printLintReport(w io.Writer, report *templates.LintReport) {
+	if len(report.SyntaxErrors) > 0 {
+		fmt.Fprintln(w, "Syntax errors:")
+		for _, syntaxErr := range report.SyntaxErrors {
+			fmt.Fprintf(w, "  %s\n", syntaxErr)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "Recipients: %d\n", report.Recipients)
+	printList(w, "Placeholders referenced by the template", report.Placeholders)
+	printList(w, "Data keys not used by the template", report.Unused)
+
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(w, "Recipients missing placeholders (%d):\n", len(report.Missing))
+		for _, missing := range report.Missing {
+			fmt.Fprintf(w, "  %s: %s\n", missing.Recipient, strings.Join(missing.Placeholders, ", "))
+		}
+	}
+}
+
+func printList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s (%d):\n", title, len(items))
+	for _, item := range items {
+		fmt.Fprintf(w, "  %s\n", item)
+	}
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate_Clean(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello {{.name}}!")
+	data := writeFile(t, dir, "data.yaml", "alice:\n  name: Alice\n")
+	app, stdout, stderr := newTestApp()
+
+	code := app.Run([]string{"validate", "--template", tmpl, "--data", data})
+
+	if code != exitOK {
+		t.Fatalf("validate exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if !strings.Contains(stdout.String(), "OK: no problems found") {
+		t.Errorf("validate stdout = %q", stdout.String())
+	}
+}
+
+func TestValidate_MissingPlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello {{.name}}, {{.email}}")
+	data := writeFile(t, dir, "data.json", `{"alice": {"name": "Alice", "phone": "1"}}`)
+	app, stdout, stderr := newTestApp()
+
+	code := app.Run([]string{"validate", "--template", tmpl, "--data", data})
+
+	if code != exitFailure {
+		t.Errorf("validate exit code = %d, want %d", code, exitFailure)
+	}
+	for _, want := range []string{"Recipients missing placeholders (1):", "alice: email", "Data keys not used by the template (1):", "phone"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("validate stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+	if !strings.Contains(stderr.String(), errValidationFailed.Error()) {
+		t.Errorf("validate stderr = %q", stderr.String())
+	}
+}
+
+func TestValidate_MissingKeyDefault(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello {{.name}}, {{.email}}")
+	data := writeFile(t, dir, "data.json", `{"alice": {"name": "Alice"}}`)
+
+	tests := []struct {
+		name     string
+		policy   string
+		wantCode int
+	}{
+		{name: "fail", policy: "fail", wantCode: exitFailure},
+		{name: "skip", policy: "skip", wantCode: exitFailure},
+		{name: "default", policy: "default", wantCode: exitOK},
+		{name: "unknown", policy: "ignore", wantCode: exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, _ := newTestApp()
+			if code := app.Run([]string{"validate", "--template", tmpl, "--data", data, "--missing-key", tt.policy}); code != tt.wantCode {
+				t.Errorf("validate exit code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidate_SyntaxError(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello\n{{.name}")
+	data := writeFile(t, dir, "data.csv", "name\nAlice\n")
+	app, stdout, _ := newTestApp()
+
+	code := app.Run([]string{"validate", "--template", tmpl, "--data", data})
+
+	if code != exitFailure {
+		t.Errorf("validate exit code = %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(stdout.String(), "line 2, column 8") {
+		t.Errorf("validate stdout = %q, want syntax error position", stdout.String())
+	}
+}
+
+func TestValidate_StrictFailsOnUnusedKeys(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello {{.name}}")
+	data := writeFile(t, dir, "data.json", `{"alice": {"name": "Alice", "phone": "1"}}`)
+
+	app, _, _ := newTestApp()
+	if code := app.Run([]string{"validate", "--template", tmpl, "--data", data}); code != exitOK {
+		t.Errorf("validate exit code = %d, want %d", code, exitOK)
+	}
+
+	app, _, _ = newTestApp()
+	if code := app.Run([]string{"validate", "--strict", "--template", tmpl, "--data", data}); code != exitFailure {
+		t.Errorf("validate --strict exit code = %d, want %d", code, exitFailure)
+	}
+}
+
+func TestValidate_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "missing flags", args: []string{"validate"}},
+		{name: "unknown flag", args: []string{"validate", "--nope"}},
+		{name: "extra arguments", args: []string{"validate", "--template", "a", "--data", "b.json", "extra"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, _ := newTestApp()
+			if code := app.Run(tt.args); code != exitUsage {
+				t.Errorf("validate exit code = %d, want %d", code, exitUsage)
+			}
+		})
+	}
+}
+
+func TestValidate_UnsupportedDataFormat(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello")
+	data := writeFile(t, dir, "data.xml", "<data/>")
+	app, _, stderr := newTestApp()
+
+	if code := app.Run([]string{"validate", "--template", tmpl, "--data", data}); code != exitFailure {
+		t.Errorf("validate exit code = %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(stderr.String(), "no parser registered") {
+		t.Errorf("validate stderr = %q", stderr.String())
+	}
+}
+
+func TestValidate_Partials(t *testing.T) {
+	dir := t.TempDir()
+	partials := t.TempDir()
+	writeFile(t, partials, "base.tmpl", `<p>{{template "content" .}}</p>{{template "footer" .}}`)
+	writeFile(t, partials, "footer.tmpl", "-- {{.sender}}")
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello {{.name}}")
+	data := writeFile(t, dir, "data.yaml", "alice:\n  name: Alice\n")
+	app, stdout, _ := newTestApp()
+
+	code := app.Run([]string{"validate", "--template", tmpl, "--data", data, "--partials", partials, "--layout", "base"})
+
+	if code != exitFailure {
+		t.Errorf("validate exit code = %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(stdout.String(), "alice: sender") {
+		t.Errorf("validate stdout = %q, want sender from the footer partial missing", stdout.String())
+	}
+}
+
+func TestValidate_FrontMatterDataFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "data.yaml", "alice:\n  name: Alice\n")
+	tmpl := writeFile(t, dir, "msg.tmpl", "---\nsubject: Hello\ndata: data.yaml\n---\nHello {{.name}}!")
+	app, stdout, stderr := newTestApp()
+
+	code := app.Run([]string{"validate", "--template", tmpl})
+
+	if code != exitOK {
+		t.Fatalf("validate exit code = %d, want %d; stderr: %s", code, exitOK, stderr)
+	}
+	if !strings.Contains(stdout.String(), "Recipients: 1") {
+		t.Errorf("validate stdout = %q, want recipients from the front matter data file", stdout.String())
+	}
+}
+
+func TestValidate_NoDataFile(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := writeFile(t, dir, "msg.tmpl", "Hello {{.name}}!")
+	app, _, stderr := newTestApp()
+
+	code := app.Run([]string{"validate", "--template", tmpl})
+
+	if code != exitFailure {
+		t.Errorf("validate exit code = %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(stderr.String(), errNoDataFile.Error()) {
+		t.Errorf("validate stderr = %q", stderr.String())
+	}
+}
@@ -0,0 +1,9 @@
+package dedup
+
+import "errors"
+
+var (
+	// Store errors
+	errReadFailed  = errors.New("failed to read sent message store")
+	errWriteFailed = errors.New("failed to write sent message store")
+)
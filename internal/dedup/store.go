@@ -0,0 +1,183 @@
+// Package dedup remembers which messages were sent recently, so running the same send
+// twice by accident does not message everyone twice
+package dedup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/filelock"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/teams"
+)
+
+// record is a line of the store file
+type record struct {
+	Key    string    `json:"key"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// Store holds the keys of sent messages with the time they were last sent.
+// It is backed by a JSON Lines file that sends are appended to. It is safe for concurrent use,
+// also by several processes sharing the file.
+type Store struct {
+	path string
+	now  func() time.Time
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// Open loads the store at path. A missing file is an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, now: time.Now, sent: make(map[string]time.Time)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load merges the records of the store file into the store
+func (s *Store) load() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", errReadFailed, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// losing a record only weakens the guard, so a damaged line is not fatal
+			initializers.Logger.Warn("Ignoring damaged sent message record", "path", s.path, "error", err)
+			continue
+		}
+		if r.SentAt.After(s.sent[r.Key]) {
+			s.sent[r.Key] = r.SentAt
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %w", errReadFailed, err)
+	}
+	return nil
+}
+
+// Key identifies the message sent to recipient at target by its content
+func Key(recipient, target string, msg teams.Message) string {
+	return msg.Hash(recipient, target)
+}
+
+// SentWithin returns when the message with key was last sent, if that was less than window ago
+func (s *Store) SentWithin(key string, window time.Duration) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sentAt, ok := s.sent[key]
+	if !ok || s.now().Sub(sentAt) >= window {
+		return time.Time{}, false
+	}
+	return sentAt, true
+}
+
+// Add records that the message with key was sent now
+func (s *Store) Add(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := record{Key: key, SentAt: s.now().UTC()}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	s.sent[key] = r.SentAt
+	return nil
+}
+
+// Prune forgets messages sent longer than keep ago and rewrites the store file without them
+func (s *Store) Prune(keep time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// other processes may have appended since the store was opened
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	cutoff := s.now().Add(-keep)
+	removed := 0
+	for key, sentAt := range s.sent {
+		if sentAt.Before(cutoff) {
+			delete(s.sent, key)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return nil
+	}
+
+	var data []byte
+	for key, sentAt := range s.sent {
+		line, err := json.Marshal(record{Key: key, SentAt: sentAt})
+		if err != nil {
+			return fmt.Errorf("%w: %w", errWriteFailed, err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	// replace the file in one step so a crash cannot leave it half written
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	initializers.Logger.Debug("Pruned sent message store", "removed", removed, "kept", len(s.sent))
+	return nil
+}
+
+// lock takes the lock file that keeps processes from appending to the store file
+// while it is rewritten, returning the function releasing it
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	unlock, err := filelock.Acquire(s.path + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	return unlock, nil
+}
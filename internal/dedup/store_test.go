@@ -0,0 +1,124 @@
+package dedup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/teams"
+)
+
+func TestStore_SentWithin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "sent.jsonl")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	key := Key("alice", "user:alice@example.com", teams.Message{Body: "<p>Hi</p>"})
+	if err := store.Add(key); err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	tests := []struct {
+		name   string
+		key    string
+		later  time.Duration
+		window time.Duration
+		want   bool
+	}{
+		{name: "within window", key: key, later: time.Hour, window: 2 * time.Hour, want: true},
+		{name: "after window", key: key, later: 2 * time.Hour, window: 2 * time.Hour, want: false},
+		{name: "other message", key: Key("alice", "user:alice@example.com", teams.Message{Body: "<p>Bye</p>"}), window: time.Hour, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reopened.now = func() time.Time { return now.Add(tt.later) }
+			sentAt, got := reopened.SentWithin(tt.key, tt.window)
+			if got != tt.want || (got && !sentAt.Equal(now)) {
+				t.Errorf("SentWithin() = %v, %v, want %v", sentAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sent.jsonl")
+	content := `{"key":"old","sent_at":"2026-01-01T00:00:00Z"}
+not json
+{"key":"new","sent_at":"2026-10-18T00:00:00Z"}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	store.now = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+
+	if err := store.Prune(24 * time.Hour); err != nil {
+		t.Fatalf("Prune() unexpected error: %v", err)
+	}
+
+	pruned, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	if _, ok := pruned.sent["old"]; ok || len(pruned.sent) != 1 {
+		t.Errorf("store after Prune() = %v, want only the new message", pruned.sent)
+	}
+}
+
+func TestStore_PruneKeepsOtherProcessRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sent.jsonl")
+	if err := os.WriteFile(path, []byte(`{"key":"old","sent_at":"2026-01-01T00:00:00Z"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pruning, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	other, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	pruning.now = func() time.Time { return now }
+	other.now = func() time.Time { return now }
+
+	if err := other.Add("appended"); err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+	if err := pruning.Prune(24 * time.Hour); err != nil {
+		t.Fatalf("Prune() unexpected error: %v", err)
+	}
+
+	pruned, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	if _, ok := pruned.sent["appended"]; !ok || len(pruned.sent) != 1 {
+		t.Errorf("store after Prune() = %v, want only the record appended by the other store", pruned.sent)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left after Prune(): %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	msg := teams.Message{Subject: "Hi", Body: "<p>Hello</p>"}
+	if Key("alice", "chat:1", msg) == Key("bob", "chat:1", msg) {
+		t.Errorf("Key() ignores the recipient")
+	}
+	if Key("alice", "chat:1", msg) != Key("alice", "chat:1", msg) {
+		t.Errorf("Key() is not deterministic")
+	}
+}
@@ -0,0 +1,268 @@
+// Package delivery sends rendered messages to Teams concurrently while keeping within
+// the Microsoft Graph throttling limits
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/dedup"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/journal"
+	"github.com/pzsp-teams/cli/internal/logger"
+	"github.com/pzsp-teams/cli/internal/recipients"
+	"github.com/pzsp-teams/cli/internal/teams"
+	"github.com/pzsp-teams/cli/internal/templates"
+	"github.com/pzsp-teams/cli/internal/wait"
+)
+
+// Engine defaults, chosen to stay below the Graph limits for posting messages
+const (
+	DefaultConcurrency = 4
+	DefaultRate        = 4.0
+	DefaultBurst       = 4
+)
+
+const (
+	// defaultThrottleDelay is how long to pause when a 429 response does not say
+	defaultThrottleDelay = 5 * time.Second
+	// maxThrottleRetries is how many times a throttled message is retried
+	maxThrottleRetries = 5
+)
+
+// Result is the outcome of delivering one message
+type Result struct {
+	Message templates.Message
+	// Sent is the message created in Teams, or nil if delivery failed
+	Sent *teams.SentMessage
+	// Err is the reason delivery failed, or nil
+	Err error
+	// MaybeSent is set when delivery failed after the message may have been posted,
+	// so sending it again could deliver it twice
+	MaybeSent bool
+}
+
+// Engine delivers messages through a bounded pool of workers sharing a rate limiter.
+// When Graph throttles a request, every worker pauses for the requested time before
+// the message is retried. Other failures are retried according to the retry policy.
+type Engine struct {
+	client      teams.Client
+	resolver    *teams.Resolver
+	concurrency int
+	limiter     *Limiter
+	retry       RetryPolicy
+	sleep       func(ctx context.Context, d time.Duration) error
+	random      func() float64
+	journal     *journal.Journal
+	sent        *dedup.Store
+}
+
+// Option configures an Engine
+type Option func(*Engine)
+
+// WithConcurrency sets the number of messages sent at the same time
+func WithConcurrency(n int) Option {
+	return func(e *Engine) {
+		e.concurrency = max(n, 1)
+	}
+}
+
+// WithLimiter sets the rate limiter requests wait for
+func WithLimiter(limiter *Limiter) Option {
+	return func(e *Engine) {
+		e.limiter = limiter
+	}
+}
+
+// WithRetryPolicy sets the policy failed sends are retried with
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(e *Engine) {
+		e.retry = policy
+	}
+}
+
+// WithJournal records the progress of every message in j
+func WithJournal(j *journal.Journal) Option {
+	return func(e *Engine) {
+		e.journal = j
+	}
+}
+
+// WithSentStore records every message sent in store, so it is not sent again by accident
+func WithSentStore(store *dedup.Store) Option {
+	return func(e *Engine) {
+		e.sent = store
+	}
+}
+
+// NewEngine returns an Engine sending through client. Without options it sends
+// DefaultConcurrency messages at a time at DefaultRate messages per second.
+func NewEngine(client teams.Client, opts ...Option) *Engine {
+	e := &Engine{
+		client:      client,
+		resolver:    teams.NewResolver(client),
+		concurrency: DefaultConcurrency,
+		retry:       DefaultRetryPolicy,
+		sleep:       wait.Sleep,
+		random:      rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.limiter == nil {
+		e.limiter = NewLimiter(DefaultRate, DefaultBurst)
+	}
+	return e
+}
+
+// Deliver sends every message with the given metadata and returns the results in message order.
+// Messages not yet sent when ctx is done fail with the context's error.
+func (e *Engine) Deliver(ctx context.Context, messages []templates.Message, meta templates.Metadata) []Result {
+	results := make([]Result, len(messages))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(e.concurrency, len(messages)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = e.deliver(ctx, messages[i], meta)
+			}
+		})
+	}
+	for i := range messages {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (e *Engine) deliver(ctx context.Context, message templates.Message, meta templates.Metadata) Result {
+	result := Result{Message: message}
+	if message.Target == nil {
+		result.Err = errMissingTarget
+		return result
+	}
+
+	target := message.Target.String()
+	msg := TeamsMessage(message, meta)
+	log := initializers.Logger.With("recipient", message.Recipient, "target", target)
+	entry := journal.Entry{Recipient: message.Recipient, Target: target, Hash: journal.Hash(target, msg)}
+	e.record(log, entry, journal.StatusPending)
+
+	result.Sent, result.MaybeSent, result.Err = e.send(ctx, log, *message.Target, msg)
+	switch {
+	case result.Err != nil && result.MaybeSent:
+		log.Warn("Failed to send message, it may have been posted", "error", result.Err)
+		entry.Error = result.Err.Error()
+		e.record(log, entry, journal.StatusUnknown)
+	case result.Err != nil:
+		log.Warn("Failed to send message", "error", result.Err)
+		entry.Error = result.Err.Error()
+		e.record(log, entry, journal.StatusFailed)
+		return result
+	default:
+		log.Info("Message sent", "id", result.Sent.ID)
+		entry.MessageID = result.Sent.ID
+		e.record(log, entry, journal.StatusSent)
+	}
+	// a message that may have been posted is remembered too, so it is not posted twice
+	if e.sent != nil {
+		if err := e.sent.Add(dedup.Key(message.Recipient, target, msg)); err != nil {
+			log.Error("Failed to remember sent message", "error", err)
+		}
+	}
+	return result
+}
+
+// record writes entry with status to the journal, if there is one. A journal failure
+// does not stop delivery, the message may have been sent already.
+func (e *Engine) record(log logger.Logger, entry journal.Entry, status string) {
+	if e.journal == nil {
+		return
+	}
+	entry.Status = status
+	if err := e.journal.Record(entry); err != nil {
+		log.Error("Failed to record delivery in journal", "status", status, "error", err)
+	}
+}
+
+// send resolves the message target and sends the message, retrying while Graph throttles
+// and while failures are retryable under the retry policy. On failure, it reports
+// whether an attempt may have posted the message anyway.
+func (e *Engine) send(ctx context.Context, log logger.Logger, target recipients.Target, msg teams.Message) (*teams.SentMessage, bool, error) {
+	attempt, throttled := 1, 0
+	maybeSent := false
+	for {
+		if err := e.limiter.Wait(ctx); err != nil {
+			return nil, maybeSent, err
+		}
+		log.Debug("Sending message", "attempt", attempt)
+		sent, posting, err := e.sendOnce(ctx, target, msg)
+		if err == nil {
+			return sent, false, nil
+		}
+		maybeSent = maybeSent || (posting && !notPosted(err))
+
+		if delay, ok := throttleDelay(err); ok {
+			if throttled == maxThrottleRetries {
+				return nil, maybeSent, errors.Join(errThrottled, err)
+			}
+			throttled++
+			log.Warn("Throttled by Microsoft Graph, pausing delivery", "delay", delay)
+			e.limiter.Pause(delay)
+			continue
+		}
+		if ctx.Err() != nil || !e.retry.retryable(err) {
+			return nil, maybeSent, err
+		}
+		if attempt >= e.retry.MaxAttempts {
+			return nil, maybeSent, fmt.Errorf("%w after %d attempts: %w", errGaveUp, attempt, err)
+		}
+
+		delay := e.retry.delay(attempt, err, e.random)
+		log.Warn("Send attempt failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+		if err := e.sleep(ctx, delay); err != nil {
+			return nil, maybeSent, err
+		}
+		attempt++
+	}
+}
+
+// sendOnce makes a single attempt at sending the message, reporting whether it got
+// as far as posting it, as failures before that cannot have posted anything
+func (e *Engine) sendOnce(ctx context.Context, target recipients.Target, msg teams.Message) (*teams.SentMessage, bool, error) {
+	dest, err := e.resolver.Resolve(ctx, target)
+	if err != nil {
+		return nil, false, err
+	}
+	sent, err := teams.Send(ctx, e.client, dest, msg)
+	return sent, true, err
+}
+
+// TeamsMessage converts a rendered message into the message sent to Teams
+func TeamsMessage(message templates.Message, meta templates.Metadata) teams.Message {
+	return teams.Message{
+		Subject:    message.Subject,
+		Body:       message.Body,
+		Summary:    message.Summary,
+		Importance: meta.Importance,
+	}
+}
+
+// throttleDelay reports whether err is a 429 response and how long the server asked to wait
+func throttleDelay(err error) (time.Duration, bool) {
+	var apiErr *teams.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return defaultThrottleDelay, true
+}
@@ -0,0 +1,200 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/journal"
+	"github.com/pzsp-teams/cli/internal/recipients"
+	"github.com/pzsp-teams/cli/internal/teams"
+	"github.com/pzsp-teams/cli/internal/teams/teamstest"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+const testChat = "19:project@thread.v2"
+
+func newTestServer(t *testing.T) (*teamstest.Server, teams.Client) {
+	t.Helper()
+	server := teamstest.NewServer(t)
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "c1", Name: "General"})
+	server.AddChat(testChat, "Project", "group")
+	return server, teams.NewGraphClient(teams.StaticToken("secret"), teams.WithBaseURL(server.URL))
+}
+
+// chatMessages returns n messages sent to the test chat
+func chatMessages(n int) []templates.Message {
+	messages := make([]templates.Message, n)
+	for i := range messages {
+		messages[i] = templates.Message{
+			Recipient: string(rune('a' + i)),
+			Target:    &recipients.Target{Kind: recipients.KindChat, Chat: testChat},
+			Body:      "<p>" + string(rune('a'+i)) + "</p>",
+		}
+	}
+	return messages
+}
+
+// concurrencyClient records the largest number of chat messages sent at the same time
+type concurrencyClient struct {
+	teams.Client
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (c *concurrencyClient) SendChatMessage(ctx context.Context, chatID string, msg teams.Message) (*teams.SentMessage, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	return c.Client.SendChatMessage(ctx, chatID, msg)
+}
+
+func TestEngine_Deliver(t *testing.T) {
+	server, graph := newTestServer(t)
+	client := &concurrencyClient{Client: graph}
+	messages := chatMessages(12)
+	engine := NewEngine(client, WithConcurrency(3), WithLimiter(NewLimiter(0, 1)))
+
+	results := engine.Deliver(context.Background(), messages, templates.Metadata{Importance: templates.ImportanceHigh})
+
+	if len(results) != len(messages) {
+		t.Fatalf("Deliver() returned %d results, want %d", len(results), len(messages))
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Sent == nil || result.Message.Recipient != messages[i].Recipient {
+			t.Errorf("result %d = %+v, want %s sent", i, result, messages[i].Recipient)
+		}
+	}
+	if posted := server.Messages(); len(posted) != len(messages) || posted[0].Importance != templates.ImportanceHigh {
+		t.Errorf("server received %d messages (%+v), want %d with high importance", len(posted), posted, len(messages))
+	}
+	if client.peak != 3 {
+		t.Errorf("peak concurrency = %d, want 3", client.peak)
+	}
+}
+
+func TestEngine_Deliver_Throttled(t *testing.T) {
+	server, client := newTestServer(t)
+	server.Fail(2, http.StatusTooManyRequests, "TooManyRequests", 7*time.Second)
+	clock := newFakeClock()
+	start := clock.Now()
+	engine := NewEngine(client, WithConcurrency(1), WithLimiter(newLimiter(0, 1, clock.Now, clock.Sleep)))
+
+	results := engine.Deliver(context.Background(), chatMessages(2), templates.Metadata{})
+
+	for i, result := range results {
+		if result.Err != nil {
+			t.Errorf("result %d error = %v, want sent after throttling", i, result.Err)
+		}
+	}
+	if len(server.Messages()) != 2 || server.Requests() != 4 {
+		t.Errorf("server received %d messages in %d requests, want 2 in 4", len(server.Messages()), server.Requests())
+	}
+	if elapsed := clock.Elapsed(start); elapsed != 14*time.Second {
+		t.Errorf("delivery paused for %v, want 14s", elapsed)
+	}
+}
+
+func TestEngine_Deliver_Failures(t *testing.T) {
+	server, client := newTestServer(t)
+	server.Fail(maxThrottleRetries+1, http.StatusTooManyRequests, "TooManyRequests", time.Second)
+	clock := newFakeClock()
+	engine := NewEngine(client, WithConcurrency(1), WithLimiter(newLimiter(0, 1, clock.Now, clock.Sleep)))
+	messages := append(chatMessages(1),
+		templates.Message{Recipient: "nowhere"},
+		templates.Message{Recipient: "unknown", Target: &recipients.Target{Kind: recipients.KindChannel, Team: "Engineering", Channel: "Nope"}})
+
+	results := engine.Deliver(context.Background(), messages, templates.Metadata{})
+
+	var apiErr *teams.APIError
+	if !errors.Is(results[0].Err, errThrottled) || !errors.As(results[0].Err, &apiErr) {
+		t.Errorf("throttled result error = %v, want %v", results[0].Err, errThrottled)
+	}
+	if !errors.Is(results[1].Err, errMissingTarget) {
+		t.Errorf("result without target error = %v, want %v", results[1].Err, errMissingTarget)
+	}
+	if results[2].Err == nil || results[2].Sent != nil {
+		t.Errorf("unresolvable result = %+v, want an error", results[2])
+	}
+}
+
+func TestEngine_Deliver_Cancelled(t *testing.T) {
+	server, client := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewEngine(client).Deliver(ctx, chatMessages(3), templates.Metadata{})
+
+	for i, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result %d error = %v, want %v", i, result.Err, context.Canceled)
+		}
+	}
+	if server.Requests() != 0 {
+		t.Errorf("server received %d requests, want none", server.Requests())
+	}
+}
+
+// cancellingClient posts chat messages and then cancels the send, as if the run
+// was interrupted while waiting for the reply
+type cancellingClient struct {
+	teams.Client
+	cancel context.CancelFunc
+}
+
+func (c *cancellingClient) SendChatMessage(ctx context.Context, chatID string, msg teams.Message) (*teams.SentMessage, error) {
+	if _, err := c.Client.SendChatMessage(context.Background(), chatID, msg); err != nil {
+		return nil, err
+	}
+	c.cancel()
+	return nil, &url.Error{Op: http.MethodPost, URL: chatID, Err: ctx.Err()}
+}
+
+func TestEngine_Deliver_CancelledInFlight(t *testing.T) {
+	server, graph := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	journalDir := t.TempDir()
+	j, err := journal.Open(journalDir, "run-1")
+	if err != nil {
+		t.Fatalf("journal.Open() unexpected error: %v", err)
+	}
+	engine := NewEngine(&cancellingClient{Client: graph, cancel: cancel}, WithConcurrency(1), WithJournal(j))
+
+	results := engine.Deliver(ctx, chatMessages(2), templates.Metadata{})
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	if !errors.Is(results[0].Err, context.Canceled) || !results[0].MaybeSent {
+		t.Errorf("result of the interrupted send = %+v, want it cancelled and maybe sent", results[0])
+	}
+	if !errors.Is(results[1].Err, context.Canceled) || results[1].MaybeSent {
+		t.Errorf("result of the send never started = %+v, want it cancelled and not sent", results[1])
+	}
+	if len(server.Messages()) != 1 {
+		t.Errorf("server received %d messages, want 1", len(server.Messages()))
+	}
+	// resume sends again only the messages recorded as failed
+	entries, err := journal.Load(journalDir, "run-1")
+	if err != nil {
+		t.Fatalf("journal.Load() unexpected error: %v", err)
+	}
+	if entries["a"].Status != journal.StatusUnknown || entries["b"].Status != journal.StatusFailed {
+		t.Errorf("journal entries = %+v, want a unknown and b failed", entries)
+	}
+}
@@ -0,0 +1,14 @@
+package delivery
+
+import "errors"
+
+var (
+	// Delivery errors
+	errMissingTarget = errors.New("message has no target")
+	errThrottled     = errors.New("still throttled by Microsoft Graph after retrying")
+	errGaveUp        = errors.New("giving up")
+
+	// Retry policy errors
+	errInvalidStatus      = errors.New("invalid HTTP status")
+	errInvalidRetryPolicy = errors.New("invalid retry policy")
+)
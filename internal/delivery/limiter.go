@@ -0,0 +1,81 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/wait"
+)
+
+// Limiter is a token bucket rate limiter shared by the delivery workers. Besides limiting
+// the request rate it can be paused, holding every worker off while the server throttles.
+type Limiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu          sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewLimiter returns a limiter allowing rate requests per second on average and bursts
+// of up to burst requests. A rate of 0 or less allows any number of requests.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return newLimiter(rate, burst, time.Now, wait.Sleep)
+}
+
+func newLimiter(rate float64, burst int, now func() time.Time, sleep func(context.Context, time.Duration) error) *Limiter {
+	burst = max(burst, 1)
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: now(), now: now, sleep: sleep}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if err := l.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Pause holds off all requests for d from now, extending any pause already in effect
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// reserve takes a token if one is available and returns 0,
+// else returns how long to wait before trying again
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return 0
+	}
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,83 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose time advances only when something sleeps on it
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.slept = append(c.slept, d)
+	return ctx.Err()
+}
+
+func (c *fakeClock) Elapsed(since time.Time) time.Duration {
+	return c.Now().Sub(since)
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		burst    int
+		pause    time.Duration
+		requests int
+		want     time.Duration
+	}{
+		{name: "within burst", rate: 2, burst: 3, requests: 3, want: 0},
+		{name: "beyond burst", rate: 2, burst: 3, requests: 7, want: 2 * time.Second},
+		{name: "unlimited", rate: 0, burst: 1, requests: 100, want: 0},
+		{name: "paused", rate: 0, burst: 1, pause: 3 * time.Second, requests: 2, want: 3 * time.Second},
+		{name: "paused with rate", rate: 1, burst: 1, pause: 3 * time.Second, requests: 2, want: 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			start := clock.Now()
+			limiter := newLimiter(tt.rate, tt.burst, clock.Now, clock.Sleep)
+			limiter.Pause(tt.pause)
+
+			for range tt.requests {
+				if err := limiter.Wait(context.Background()); err != nil {
+					t.Fatalf("Wait() unexpected error: %v", err)
+				}
+			}
+			if got := clock.Elapsed(start); got != tt.want {
+				t.Errorf("%d requests took %v, want %v", tt.requests, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiter_Wait_Cancelled(t *testing.T) {
+	limiter := NewLimiter(1, 1)
+	limiter.Pause(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := limiter.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,161 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/teams"
+)
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min, Max int
+}
+
+// Contains reports whether status is in the range
+func (r StatusRange) Contains(status int) bool {
+	return status >= r.Min && status <= r.Max
+}
+
+// String returns the range as accepted by ParseStatusRanges
+func (r StatusRange) String() string {
+	if r.Min == r.Max {
+		return strconv.Itoa(r.Min)
+	}
+	if r.Min%100 == 0 && r.Max == r.Min+99 {
+		return strconv.Itoa(r.Min/100) + "xx"
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ParseStatusRanges parses a comma separated list of HTTP statuses and status classes,
+// e.g. "408,5xx"
+func ParseStatusRanges(s string) ([]StatusRange, error) {
+	var ranges []StatusRange
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if class, ok := strings.CutSuffix(field, "xx"); ok {
+			n, err := strconv.Atoi(class)
+			if err != nil || n < 1 || n > 5 {
+				return nil, fmt.Errorf("%w %q", errInvalidStatus, field)
+			}
+			ranges = append(ranges, StatusRange{Min: n * 100, Max: n*100 + 99})
+			continue
+		}
+		status, err := strconv.Atoi(field)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("%w %q", errInvalidStatus, field)
+		}
+		ranges = append(ranges, StatusRange{Min: status, Max: status})
+	}
+	return ranges, nil
+}
+
+// RetryPolicy decides which failed sends are retried and how long to wait in between.
+// The wait doubles with every attempt, starting from BaseDelay, up to MaxDelay.
+// Throttled (429) requests are not subject to the policy; they are retried after
+// the delay the server asks for.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per message including the first; 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts
+	MaxDelay time.Duration
+	// Jitter is the fraction of each wait that is randomized, from 0 to 1,
+	// so workers failing together do not retry together
+	Jitter float64
+	// RetryOn are the HTTP statuses retried. Connection failures, where the request
+	// never reached the server, are always retried.
+	RetryOn []StatusRange
+	// RetryNetworkErrors also retries network errors after the request may have reached
+	// the server, such as timeouts and broken connections. The message may have been
+	// posted before the error, so a retry can post it twice.
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy retries twice when the message was certainly not posted:
+// on connection failures and when the service is unavailable (503). Other server
+// errors are not retried by default, since posting a message is not idempotent
+// and a retry could deliver it twice.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+	Jitter:      0.2,
+	RetryOn:     []StatusRange{{Min: http.StatusServiceUnavailable, Max: http.StatusServiceUnavailable}},
+}
+
+// Validate checks that the policy values are within range
+func (p RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 1:
+		return fmt.Errorf("%w: max attempts must be at least 1", errInvalidRetryPolicy)
+	case p.BaseDelay < 0 || p.MaxDelay < 0:
+		return fmt.Errorf("%w: delays must not be negative", errInvalidRetryPolicy)
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("%w: jitter must be between 0 and 1", errInvalidRetryPolicy)
+	}
+	return nil
+}
+
+// retryable reports whether a send that failed with err may succeed if tried again
+func (p RetryPolicy) retryable(err error) bool {
+	var apiErr *teams.APIError
+	if errors.As(err, &apiErr) {
+		for _, r := range p.RetryOn {
+			if r.Contains(apiErr.StatusCode) {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	return connectionFailed(err) || (p.RetryNetworkErrors && errors.As(err, &netErr))
+}
+
+// connectionFailed reports whether err means the request never reached the server,
+// such as a failed DNS lookup or a refused connection
+func connectionFailed(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// notPosted reports whether a post that failed with err certainly did not create
+// the message: the server rejected it with a client error or as unavailable (503),
+// or the connection to the server failed
+func notPosted(err error) bool {
+	var apiErr *teams.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode < http.StatusInternalServerError || apiErr.StatusCode == http.StatusServiceUnavailable
+	}
+	return connectionFailed(err)
+}
+
+// delay returns the wait before the retry following attempt, at least as long as
+// the server asked for in err. random returns a number in [0, 1).
+func (p RetryPolicy) delay(attempt int, err error, random func() float64) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	delay = min(delay, float64(p.MaxDelay))
+	delay += delay * p.Jitter * (2*random() - 1)
+	wait := min(time.Duration(delay), p.MaxDelay)
+
+	var apiErr *teams.APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+		return apiErr.RetryAfter
+	}
+	return wait
+}
@@ -0,0 +1,211 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/teams"
+	"github.com/pzsp-teams/cli/internal/templates"
+)
+
+func TestParseStatusRanges(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []StatusRange
+		wantErr error
+	}{
+		{input: "408, 5xx", want: []StatusRange{{408, 408}, {500, 599}}},
+		{input: "4XX,", want: []StatusRange{{400, 499}}},
+		{input: "", want: nil},
+		{input: "6xx", wantErr: errInvalidStatus},
+		{input: "99", wantErr: errInvalidStatus},
+		{input: "server", wantErr: errInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseStatusRanges(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseStatusRanges() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseStatusRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	networkErrors := DefaultRetryPolicy
+	networkErrors.RetryNetworkErrors = true
+	timeout := &url.Error{Op: "Post", URL: "https://graph.example", Err: context.DeadlineExceeded}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		err    error
+		want   bool
+	}{
+		{name: "unavailable", policy: DefaultRetryPolicy, err: fmt.Errorf("wrapped: %w", &teams.APIError{StatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "ambiguous server error", policy: DefaultRetryPolicy, err: &teams.APIError{StatusCode: http.StatusBadGateway}, want: false},
+		{name: "client error", policy: DefaultRetryPolicy, err: &teams.APIError{StatusCode: http.StatusForbidden}, want: false},
+		{name: "connection refused", policy: DefaultRetryPolicy, err: fmt.Errorf("wrapped: %w", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), want: true},
+		{name: "dns failure", policy: DefaultRetryPolicy, err: &net.DNSError{Err: "no such host", Name: "graph.example"}, want: true},
+		{name: "connection reset", policy: DefaultRetryPolicy, err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: false},
+		{name: "timeout", policy: DefaultRetryPolicy, err: timeout, want: false},
+		{name: "other error", policy: DefaultRetryPolicy, err: errors.New("no channel named"), want: false},
+		{name: "connection reset with network errors", policy: networkErrors, err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "timeout with network errors", policy: networkErrors, err: timeout, want: true},
+		{name: "ambiguous server error with network errors", policy: networkErrors, err: &teams.APIError{StatusCode: http.StatusBadGateway}, want: false},
+		{name: "other error with network errors", policy: networkErrors, err: errors.New("no channel named"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.retryable(tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotPosted(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "client error", err: &teams.APIError{StatusCode: http.StatusForbidden}, want: true},
+		{name: "throttled", err: &teams.APIError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "unavailable", err: &teams.APIError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "ambiguous server error", err: &teams.APIError{StatusCode: http.StatusInternalServerError}, want: false},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: false},
+		{name: "cancelled", err: &url.Error{Op: "Post", URL: "https://graph.example", Err: context.Canceled}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notPosted(tt.err); got != tt.want {
+				t.Errorf("notPosted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+	tests := []struct {
+		name    string
+		attempt int
+		random  float64
+		err     error
+		want    time.Duration
+	}{
+		{name: "first retry", attempt: 1, random: 0.5, want: time.Second},
+		{name: "doubles", attempt: 3, random: 0.5, want: 4 * time.Second},
+		{name: "capped", attempt: 8, random: 0.5, want: 10 * time.Second},
+		{name: "jitter down", attempt: 2, random: 0, want: time.Second},
+		{name: "jitter capped", attempt: 8, random: 0.99, want: 10 * time.Second},
+		{name: "retry after", attempt: 1, random: 0.5, err: &teams.APIError{StatusCode: 503, RetryAfter: 7 * time.Second}, want: 7 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.delay(tt.attempt, tt.err, func() float64 { return tt.random })
+			if got != tt.want {
+				t.Errorf("delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	invalid := []RetryPolicy{
+		{MaxAttempts: 0},
+		{MaxAttempts: 1, BaseDelay: -time.Second},
+		{MaxAttempts: 1, Jitter: 1.5},
+	}
+	if err := DefaultRetryPolicy.Validate(); err != nil {
+		t.Errorf("DefaultRetryPolicy.Validate() unexpected error: %v", err)
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); !errors.Is(err, errInvalidRetryPolicy) {
+			t.Errorf("Validate(%+v) error = %v, want %v", policy, err, errInvalidRetryPolicy)
+		}
+	}
+}
+
+// newRetryEngine returns an engine sending one message at a time without jitter,
+// waiting on clock
+func newRetryEngine(client teams.Client, clock *fakeClock) *Engine {
+	policy := DefaultRetryPolicy
+	policy.Jitter = 0
+	engine := NewEngine(client, WithConcurrency(1), WithRetryPolicy(policy),
+		WithLimiter(newLimiter(0, 1, clock.Now, clock.Sleep)))
+	engine.sleep = clock.Sleep
+	return engine
+}
+
+func TestEngine_Deliver_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		wantSent     bool
+		wantGaveUp   bool
+		wantRequests int
+		wantWaits    []time.Duration
+	}{
+		{name: "recovers", failures: 2, status: http.StatusServiceUnavailable, wantSent: true, wantRequests: 3, wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "gives up", failures: 3, status: http.StatusServiceUnavailable, wantGaveUp: true, wantRequests: 3, wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "not retryable", failures: 1, status: http.StatusForbidden, wantRequests: 1},
+		{name: "ambiguous", failures: 1, status: http.StatusInternalServerError, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := newTestServer(t)
+			server.Fail(tt.failures, tt.status, "Injected", 0)
+			clock := newFakeClock()
+
+			results := newRetryEngine(client, clock).Deliver(context.Background(), chatMessages(1), templates.Metadata{})
+
+			err := results[0].Err
+			if (err == nil) != tt.wantSent || errors.Is(err, errGaveUp) != tt.wantGaveUp {
+				t.Errorf("Deliver() error = %v, want sent %v, gave up %v", err, tt.wantSent, tt.wantGaveUp)
+			}
+			if server.Requests() != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", server.Requests(), tt.wantRequests)
+			}
+			if !reflect.DeepEqual(clock.slept, tt.wantWaits) {
+				t.Errorf("waited %v between attempts, want %v", clock.slept, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestEngine_Deliver_RetriesNetworkErrors(t *testing.T) {
+	server, _ := newTestServer(t)
+	url := server.URL
+	server.Close()
+	client := teams.NewGraphClient(teams.StaticToken("secret"), teams.WithBaseURL(url))
+	clock := newFakeClock()
+
+	results := newRetryEngine(client, clock).Deliver(context.Background(), chatMessages(1), templates.Metadata{})
+
+	if !errors.Is(results[0].Err, errGaveUp) {
+		t.Errorf("Deliver() error = %v, want %v", results[0].Err, errGaveUp)
+	}
+	if len(clock.slept) != DefaultRetryPolicy.MaxAttempts-1 {
+		t.Errorf("waited %d times, want %d", len(clock.slept), DefaultRetryPolicy.MaxAttempts-1)
+	}
+}
@@ -0,0 +1,9 @@
+package filelock
+
+import "errors"
+
+var (
+	// Lock errors
+	errTimeout = errors.New("lock file is held by another process")
+	errHeld    = errors.New("lock file is held")
+)
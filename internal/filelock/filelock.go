@@ -0,0 +1,102 @@
+// Package filelock provides lock files that keep processes sharing a file
+// from changing it at the same time
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+const (
+	// timeout is how long to wait for another process to release a lock
+	timeout = 5 * time.Second
+	// retryDelay is how often a held lock is tried again
+	retryDelay = 10 * time.Millisecond
+	// staleAge is the age after which a lock is taken to be left by a crashed process.
+	// Locks are held for single file updates or kept fresh by Hold, so live ones are far younger.
+	staleAge = time.Minute
+	// refreshInterval is how often Hold refreshes the locks it keeps
+	refreshInterval = staleAge / 4
+)
+
+// Acquire creates the lock file at path, waiting while another process holds it,
+// and returns the function releasing it. The directory of path must exist.
+func Acquire(path string) (func(), error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = file.Close()
+			return func() {
+				if err := os.Remove(path); err != nil {
+					initializers.Logger.Warn("Failed to remove lock file", "path", path, "error", err)
+				}
+			}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleAge {
+			initializers.Logger.Warn("Removing stale lock file", "path", path, "modified", info.ModTime())
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", errTimeout, path)
+		}
+		time.Sleep(retryDelay)
+	}
+}
+
+// Hold creates the lock file at path without waiting, for locks kept longer than a single
+// file update, and returns the function releasing it. Until released, the lock is refreshed
+// in the background, so only a lock left by a crashed process becomes stale and can be taken over.
+func Hold(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, fs.ErrExist) && !Held(path) {
+		initializers.Logger.Warn("Taking over stale lock file", "path", path)
+		_ = os.Remove(path)
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("%w: %s", errHeld, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = file.Close()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				if err := os.Chtimes(path, now, now); err != nil {
+					initializers.Logger.Warn("Failed to refresh lock file", "path", path, "error", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			initializers.Logger.Warn("Failed to remove lock file", "path", path, "error", err)
+		}
+	}, nil
+}
+
+// Held reports whether the lock file at path exists and is not stale
+func Held(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) <= staleAge
+}
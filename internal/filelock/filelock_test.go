@@ -0,0 +1,95 @@
+package filelock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.lock")
+	release, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error: %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		release, err := Acquire(path)
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("second Acquire() returned %v while the lock was held", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	if err := <-acquired; err != nil {
+		t.Errorf("second Acquire() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file left after release: %v", err)
+	}
+}
+
+func TestAcquire_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.lock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleAge)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() of a stale lock unexpected error: %v", err)
+	}
+	release()
+}
+
+func TestHold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.lock")
+	release, err := Hold(path)
+	if err != nil {
+		t.Fatalf("Hold() unexpected error: %v", err)
+	}
+	if !Held(path) {
+		t.Errorf("Held() = false while the lock is held")
+	}
+	if _, err := Hold(path); !errors.Is(err, errHeld) {
+		t.Errorf("second Hold() error = %v, want %v", err, errHeld)
+	}
+
+	release()
+	if Held(path) {
+		t.Errorf("Held() = true after release")
+	}
+}
+
+func TestHold_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.lock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleAge)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if Held(path) {
+		t.Errorf("Held() = true for a stale lock")
+	}
+
+	release, err := Hold(path)
+	if err != nil {
+		t.Fatalf("Hold() of a stale lock unexpected error: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,12 @@
+package journal
+
+import "errors"
+
+var (
+	// Journal errors
+	errInvalidRunID = errors.New("invalid run ID")
+	errUnknownRun   = errors.New("no journal for run")
+	errOpenFailed   = errors.New("failed to open delivery journal")
+	errReadFailed   = errors.New("failed to read delivery journal")
+	errWriteFailed  = errors.New("failed to write delivery journal")
+)
@@ -0,0 +1,172 @@
+// Package journal records the progress of sends in append-only JSON Lines files,
+// one per run, so an interrupted send can be resumed without messaging anyone twice
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/teams"
+)
+
+// Delivery statuses recorded in the journal
+const (
+	// StatusPending is recorded before a message is sent. A recipient left pending
+	// by a crash may or may not have received the message.
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	// StatusFailed is recorded when the message was certainly not posted
+	StatusFailed = "failed"
+	// StatusUnknown is recorded when sending failed after the message may have been
+	// posted, e.g. when the connection broke or the run was cancelled mid-request
+	StatusUnknown = "unknown"
+)
+
+// runIDRegex matches run IDs, which are also file names
+var runIDRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Entry is one line of the journal: the status of the message for a recipient at a point in time
+type Entry struct {
+	RunID     string `json:"run_id"`
+	Recipient string `json:"recipient"`
+	Target    string `json:"target"`
+	// Hash identifies the rendered message, see Hash
+	Hash   string `json:"hash"`
+	Status string `json:"status"`
+	// MessageID is the ID of the message created in Teams, if sent
+	MessageID string `json:"message_id,omitempty"`
+	// Error is the reason delivery failed, if it did
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Journal appends entries of a run to its file. It is safe for concurrent use.
+type Journal struct {
+	runID string
+	now   func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRunID returns a new run ID made of the current time and a random suffix
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// Open opens the journal of run in dir for appending, creating it if needed
+func Open(dir, runID string) (*Journal, error) {
+	path, err := Path(dir, runID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("%w: %w", errOpenFailed, err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errOpenFailed, err)
+	}
+	return &Journal{runID: runID, now: time.Now, file: file}, nil
+}
+
+// RunID returns the ID of the journal's run
+func (j *Journal) RunID() string {
+	return j.runID
+}
+
+// Record appends entry, setting its run ID and time. The entry is synced to disk
+// before Record returns, so it survives a crash right after.
+func (j *Journal) Record(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry.RunID = j.runID
+	entry.Time = j.now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	return nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// Load returns the latest entry of every recipient in the journal of run in dir
+func Load(dir, runID string) (map[string]Entry, error) {
+	path, err := Path(dir, runID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w %q", errUnknownRun, runID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errReadFailed, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	entries := make(map[string]Entry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	var lineErr error
+	for line := 1; scanner.Scan(); line++ {
+		if lineErr != nil {
+			return nil, lineErr
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			lineErr = fmt.Errorf("%w: line %d: %w", errReadFailed, line, err)
+			continue
+		}
+		entries[entry.Recipient] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", errReadFailed, err)
+	}
+	if lineErr != nil {
+		// a crash while appending can leave a partial last line
+		initializers.Logger.Warn("Ignoring incomplete last journal entry", "run", runID, "error", lineErr)
+	}
+	return entries, nil
+}
+
+// Hash returns a hash of the message sent to target, identifying the rendered content
+func Hash(target string, msg teams.Message) string {
+	return msg.Hash(target)
+}
+
+// Path returns the path of the journal of run in dir
+func Path(dir, runID string) (string, error) {
+	if !runIDRegex.MatchString(runID) {
+		return "", fmt.Errorf("%w %q", errInvalidRunID, runID)
+	}
+	return filepath.Join(dir, runID+".jsonl"), nil
+}
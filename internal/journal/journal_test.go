@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/teams"
+)
+
+func TestJournal_RecordLoad(t *testing.T) {
+	dir := t.TempDir()
+	runID := NewRunID()
+	j, err := Open(dir, runID)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	entries := []Entry{
+		{Recipient: "alice", Target: "user:alice@example.com", Hash: "h1", Status: StatusPending},
+		{Recipient: "bob", Target: "user:bob@example.com", Hash: "h2", Status: StatusPending},
+		{Recipient: "alice", Target: "user:alice@example.com", Hash: "h1", Status: StatusSent, MessageID: "m1"},
+		{Recipient: "bob", Target: "user:bob@example.com", Hash: "h2", Status: StatusFailed, Error: "403 Forbidden"},
+	}
+	for _, entry := range entries {
+		if err := j.Record(entry); err != nil {
+			t.Fatalf("Record() unexpected error: %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	loaded, err := Load(dir, runID)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if alice := loaded["alice"]; alice.Status != StatusSent || alice.MessageID != "m1" || alice.RunID != runID || alice.Time.IsZero() {
+		t.Errorf("Load() alice = %+v, want the sent entry", alice)
+	}
+	if bob := loaded["bob"]; bob.Status != StatusFailed || bob.Error != "403 Forbidden" {
+		t.Errorf("Load() bob = %+v, want the failed entry", bob)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	valid := `{"recipient":"alice","status":"sent"}` + "\n"
+	tests := []struct {
+		name    string
+		content string
+		runID   string
+		want    int
+		wantErr error
+	}{
+		{name: "partial last line", content: valid + `{"recipient":"bo`, runID: "run", want: 1},
+		{name: "corrupt line", content: `{"recipient` + "\n" + valid, runID: "run", wantErr: errReadFailed},
+		{name: "unknown run", runID: "other", wantErr: errUnknownRun},
+		{name: "invalid run ID", runID: "../run", wantErr: errInvalidRunID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "run.jsonl"), []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := Load(dir, tt.runID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("Load() returned %d entries, want %d", len(entries), tt.want)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	msg := teams.Message{Subject: "Hi", Body: "<p>Hello</p>"}
+	hash := Hash("user:alice@example.com", msg)
+
+	if hash != Hash("user:alice@example.com", msg) {
+		t.Errorf("Hash() is not deterministic")
+	}
+	if hash == Hash("user:bob@example.com", msg) {
+		t.Errorf("Hash() ignores the target")
+	}
+	if hash == Hash("user:alice@example.com", teams.Message{Subject: "Hi<p>", Body: "Hello</p>"}) {
+		t.Errorf("Hash() does not separate message parts")
+	}
+}
@@ -0,0 +1,9 @@
+package preview
+
+import "errors"
+
+var (
+	// Server errors
+	errListenFailed = errors.New("failed to listen for preview connections")
+	errServeFailed  = errors.New("preview server failed")
+)
@@ -0,0 +1,65 @@
+package preview
+
+import htmltemplate "html/template"
+
+// pageTemplate renders the preview of one recipient's message, with a list of all
+// recipients to switch between and a script reloading the page when messages change
+var pageTemplate = htmltemplate.Must(htmltemplate.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Message preview</title>
+<style>
+  body { margin: 0; display: flex; min-height: 100vh; font-family: "Segoe UI", system-ui, sans-serif; font-size: 14px; color: #242424; background: #f5f5f5; }
+  nav { width: 260px; flex-shrink: 0; background: #ebebeb; border-right: 1px solid #d1d1d1; overflow-y: auto; }
+  nav h2 { font-size: 12px; text-transform: uppercase; letter-spacing: .05em; color: #616161; margin: 16px; }
+  nav a { display: block; padding: 8px 16px; color: inherit; text-decoration: none; }
+  nav a:hover { background: #e0e0e0; }
+  nav a.selected { background: #fff; border-left: 3px solid #5b5fc7; padding-left: 13px; font-weight: 600; }
+  nav .target { display: block; font-size: 12px; font-weight: normal; color: #616161; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+  main { flex: 1; padding: 32px; }
+  .post { max-width: 720px; background: #fff; border-radius: 4px; box-shadow: 0 1px 2px rgba(0,0,0,.14); padding: 16px 20px; }
+  .post header { display: flex; gap: 8px; align-items: baseline; color: #616161; font-size: 12px; margin-bottom: 8px; }
+  .post header strong { color: #242424; font-size: 14px; }
+  .importance { color: #c4314b; font-weight: 600; text-transform: uppercase; }
+  .subject { font-size: 18px; font-weight: 600; margin: 4px 0 12px; }
+  .summary { color: #616161; font-style: italic; margin-top: 16px; border-top: 1px solid #ebebeb; padding-top: 8px; }
+  .body blockquote { border-left: 4px solid #d1d1d1; margin: 8px 0; padding-left: 12px; color: #424242; }
+  .body pre, .body code { font-family: Consolas, monospace; background: #f5f5f5; border-radius: 2px; }
+  .body pre { padding: 8px; overflow-x: auto; }
+  .body a { color: #5b5fc7; }
+  .error { max-width: 720px; background: #fdf3f4; border: 1px solid #eeacb2; color: #751d1f; border-radius: 4px; padding: 12px 16px; white-space: pre-wrap; font-family: Consolas, monospace; margin-bottom: 16px; }
+  .empty { color: #616161; }
+</style>
+</head>
+<body>
+<nav>
+  <h2>Recipients ({{len .Pages}})</h2>
+  {{range $i, $page := .Pages}}
+  <a href="?recipient={{$page.Recipient}}"{{if eq $i $.Selected}} class="selected"{{end}}>{{$page.Recipient}}<span class="target">{{or $page.Target "no target"}}</span></a>
+  {{end}}
+</nav>
+<main>
+  {{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+  {{if .Pages}}{{with index .Pages .Selected}}
+  <article class="post">
+    <header><strong>{{.Recipient}}</strong><span>{{.Target}}</span>{{if and .Importance (ne .Importance "normal")}}<span class="importance">{{.Importance}}</span>{{end}}</header>
+    {{if .Subject}}<div class="subject">{{.Subject}}</div>{{end}}
+    <div class="body">{{$.Body}}</div>
+    {{if .Summary}}<div class="summary">Notification: {{.Summary}}</div>{{end}}
+  </article>
+  {{end}}{{else}}<p class="empty">No messages to preview.</p>{{end}}
+</main>
+<script>
+  // reload when the messages are rendered again
+  const version = "{{.Version}}";
+  setInterval(async () => {
+    try {
+      const response = await fetch("version", { cache: "no-store" });
+      if (response.ok && (await response.text()) !== version) location.reload();
+    } catch (e) {}
+  }, 1000);
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,191 @@
+// Package preview serves rendered messages as web pages styled like Teams posts,
+// reloading them when their source files change
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// Page is a rendered message shown by the preview server
+type Page struct {
+	Recipient  string
+	Target     string
+	Subject    string
+	Summary    string
+	Importance string
+	// Body is the sanitized message HTML
+	Body string
+}
+
+// Source renders the current messages
+type Source func() ([]Page, error)
+
+// Server renders messages from a Source and serves them. Refresh renders them again;
+// pages viewed in a browser reload themselves when that happens.
+type Server struct {
+	source Source
+
+	mu      sync.RWMutex
+	pages   []Page
+	err     error
+	version int
+}
+
+// NewServer returns a server for the messages rendered by source and renders them
+func NewServer(source Source) *Server {
+	s := &Server{source: source}
+	s.Refresh()
+	return s
+}
+
+// Refresh renders the messages again. A render error is shown in place of the
+// messages until a later refresh succeeds.
+func (s *Server) Refresh() {
+	pages, err := s.source()
+	if err != nil {
+		initializers.Logger.Warn("Failed to render preview", "error", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages, s.err = pages, err
+	s.version++
+}
+
+// Watch records the modification times of the files under paths and then, in the
+// background, calls Refresh whenever one is modified, checking every interval until ctx
+// is done. Paths may be files or directories. Changes made after Watch returns are seen.
+func (s *Server) Watch(ctx context.Context, interval time.Duration, paths ...string) {
+	last := latestModTime(paths)
+	go s.poll(ctx, interval, paths, last)
+}
+
+// poll checks the files under paths every interval, refreshing when their latest
+// modification time moves away from last
+func (s *Server) poll(ctx context.Context, interval time.Duration, paths []string, last time.Time) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if modified := latestModTime(paths); !modified.Equal(last) {
+				initializers.Logger.Debug("Preview sources changed", "modified", modified)
+				last = modified
+				s.Refresh()
+			}
+		}
+	}
+}
+
+// latestModTime returns the latest modification time of the files under paths.
+// Missing files are ignored, so a file being replaced by an editor is picked up once it is back.
+func latestModTime(paths []string) time.Time {
+	var latest time.Time
+	for _, path := range paths {
+		_ = filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info, err := entry.Info(); err == nil && info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return latest
+}
+
+// Handler returns the HTTP handler serving the preview:
+// the page of the recipient selected by the recipient query parameter at /,
+// and the current render version, polled by the page to reload itself, at /version.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handlePage)
+	mux.HandleFunc("GET /version", s.handleVersion)
+	return mux
+}
+
+// pageView is the data of the preview page template
+type pageView struct {
+	Pages    []Page
+	Selected int
+	Body     htmltemplate.HTML
+	Error    string
+	Version  int
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	view := pageView{Pages: s.pages, Version: s.version}
+	if s.err != nil {
+		view.Error = s.err.Error()
+	}
+	s.mu.RUnlock()
+
+	recipient := r.URL.Query().Get("recipient")
+	for i, page := range view.Pages {
+		if page.Recipient == recipient {
+			view.Selected = i
+		}
+	}
+	if len(view.Pages) > 0 {
+		// bodies are sanitized when rendered, so they are safe to embed
+		view.Body = htmltemplate.HTML(view.Pages[view.Selected].Body)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := pageTemplate.Execute(w, view); err != nil {
+		initializers.Logger.Warn("Failed to write preview page", "error", err)
+	}
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	version := s.version
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(strconv.Itoa(version)))
+}
+
+// Serve serves the preview on addr until ctx is done, watching paths for changes.
+// ready is called with the server URL once it is listening.
+func (s *Server) Serve(ctx context.Context, addr string, paths []string, ready func(url string)) error {
+	server := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errListenFailed, err)
+	}
+
+	s.Watch(ctx, time.Second, paths...)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			initializers.Logger.Warn("Failed to stop preview server", "error", err)
+		}
+	}()
+
+	ready("http://" + listener.Addr().String())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%w: %w", errServeFailed, err)
+	}
+	return nil
+}
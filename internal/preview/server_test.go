@@ -0,0 +1,102 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, handler http.Handler, target string) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d, want %d", target, recorder.Code, http.StatusOK)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+func TestServer_Pages(t *testing.T) {
+	server := NewServer(func() ([]Page, error) {
+		return []Page{
+			{Recipient: "alice", Target: "user:alice@example.com", Subject: "Hi <Alice>", Body: "<p>Hello <b>Alice</b></p>", Importance: "high"},
+			{Recipient: "bob & co", Body: "<p>Hello Bob</p>"},
+		}, nil
+	})
+	handler := server.Handler()
+
+	first := get(t, handler, "/")
+	for _, want := range []string{"Recipients (2)", "<p>Hello <b>Alice</b></p>", "Hi &lt;Alice&gt;", `class="importance">high`, "?recipient=bob%20%26%20co"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("GET / does not contain %q", want)
+		}
+	}
+
+	second := get(t, handler, "/?recipient="+url.QueryEscape("bob & co"))
+	if !strings.Contains(second, "<p>Hello Bob</p>") || strings.Contains(second, "Hello <b>Alice</b>") {
+		t.Errorf("GET /?recipient=bob does not show only Bob's message")
+	}
+}
+
+func TestServer_RefreshShowsErrorsAndBumpsVersion(t *testing.T) {
+	fail := false
+	server := NewServer(func() ([]Page, error) {
+		if fail {
+			return nil, errors.New("template: message:1: unexpected EOF")
+		}
+		return []Page{{Recipient: "alice", Body: "Hi"}}, nil
+	})
+	handler := server.Handler()
+	if version := get(t, handler, "/version"); version != "1" {
+		t.Errorf("GET /version = %q, want 1", version)
+	}
+
+	fail = true
+	server.Refresh()
+
+	if version := get(t, handler, "/version"); version != "2" {
+		t.Errorf("GET /version = %q, want 2", version)
+	}
+	page := get(t, handler, "/")
+	if !strings.Contains(page, "unexpected EOF") || !strings.Contains(page, "No messages to preview.") {
+		t.Errorf("GET / does not show the render error")
+	}
+}
+
+func TestServer_WatchRefreshesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "msg.tmpl")
+	if err := os.WriteFile(path, []byte("Hi"), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	renders := make(chan struct{}, 10)
+	server := NewServer(func() ([]Page, error) {
+		renders <- struct{}{}
+		return nil, nil
+	})
+	<-renders
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.Watch(ctx, 10*time.Millisecond, dir)
+
+	modified := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatalf("Failed to touch test file: %v", err)
+	}
+
+	select {
+	case <-renders:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watch() did not refresh after the file changed")
+	}
+}
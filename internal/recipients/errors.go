@@ -0,0 +1,13 @@
+package recipients
+
+import "errors"
+
+var (
+	// Target errors
+	errUnknownKind        = errors.New("unknown target kind")
+	errMissingKind        = errors.New("target kind not given")
+	errMissingIdentifier  = errors.New("missing target identifier")
+	errUnexpectedField    = errors.New("unexpected target field")
+	errInvalidUser        = errors.New("invalid user e-mail address or UPN")
+	errInvalidTargetValue = errors.New("target must be a string or a map")
+)
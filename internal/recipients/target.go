@@ -0,0 +1,219 @@
+// Package recipients models where rendered messages are delivered:
+// team channels, group chats and 1:1 chats with users.
+package recipients
+
+import (
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+)
+
+// Kind is the type of conversation a message is delivered to
+type Kind int
+
+// Available target kinds
+const (
+	// KindChannel posts to a channel of a team
+	KindChannel Kind = iota + 1
+	// KindChat sends to an existing chat, such as a group chat, by its ID
+	KindChat
+	// KindUser sends to the 1:1 chat with a user, identified by e-mail address or UPN
+	KindUser
+)
+
+// String returns the kind name as accepted by ParseKind
+func (k Kind) String() string {
+	switch k {
+	case KindChannel:
+		return "channel"
+	case KindChat:
+		return "chat"
+	case KindUser:
+		return "user"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// ParseKind returns the kind with the given name: channel, chat or user
+func ParseKind(name string) (Kind, error) {
+	for _, k := range []Kind{KindChannel, KindChat, KindUser} {
+		if k.String() == name {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", errUnknownKind, name)
+}
+
+// Target identifies the conversation a message is delivered to.
+// Only the identifiers of its kind are set.
+type Target struct {
+	Kind Kind
+	// Team is the name or ID of the team of a channel
+	Team string
+	// Channel is the name or ID of a channel
+	Channel string
+	// Chat is the ID of a chat
+	Chat string
+	// User is the e-mail address or user principal name of a user
+	User string
+}
+
+// NewTarget returns the target of the given kind at address, which is
+// "team/channel" for channels, a chat ID for chats and an e-mail address or UPN for users.
+// Team names may contain slashes; the channel is the part after the last one.
+func NewTarget(kind Kind, address string) (Target, error) {
+	var target Target
+	switch kind {
+	case KindChannel:
+		separator := strings.LastIndex(address, "/")
+		if separator < 0 {
+			return Target{}, fmt.Errorf("%w: channel target %q is not in team/channel form", errMissingIdentifier, address)
+		}
+		target = Target{Kind: kind, Team: address[:separator], Channel: address[separator+1:]}
+	case KindChat:
+		target = Target{Kind: kind, Chat: address}
+	case KindUser:
+		target = Target{Kind: kind, User: address}
+	default:
+		return Target{}, fmt.Errorf("%w: %d", errUnknownKind, int(kind))
+	}
+	return target, target.Validate()
+}
+
+// ParseTarget parses the shorthand form of a target, the kind name and address
+// separated by a colon: "channel:Team/General", "chat:19:abc@thread.v2" or "user:alice@example.com"
+func ParseTarget(s string) (Target, error) {
+	name, address, ok := strings.Cut(s, ":")
+	if !ok {
+		return Target{}, fmt.Errorf("%w: %q is not in kind:address form", errMissingKind, s)
+	}
+	kind, err := ParseKind(strings.TrimSpace(name))
+	if err != nil {
+		return Target{}, err
+	}
+	return NewTarget(kind, strings.TrimSpace(address))
+}
+
+// HasKindPrefix reports whether s starts with a known kind name followed by a colon,
+// so it can be parsed by ParseTarget
+func HasKindPrefix(s string) bool {
+	name, _, ok := strings.Cut(s, ":")
+	if !ok {
+		return false
+	}
+	_, err := ParseKind(name)
+	return err == nil
+}
+
+// FromValue returns the target described by a decoded data file value: either the
+// shorthand string accepted by ParseTarget or a map with the fields kind, team,
+// channel, chat and user. The kind of a map may be left out when its fields imply it.
+func FromValue(value any) (Target, error) {
+	switch v := value.(type) {
+	case string:
+		return ParseTarget(v)
+	case map[string]any:
+		return fromMap(v)
+	default:
+		return Target{}, fmt.Errorf("%w, got %T", errInvalidTargetValue, value)
+	}
+}
+
+func fromMap(fields map[string]any) (Target, error) {
+	values := make(map[string]string, len(fields))
+	for key, value := range fields {
+		switch key {
+		case "kind", "team", "channel", "chat", "user":
+			values[key] = strings.TrimSpace(fmt.Sprint(value))
+		default:
+			return Target{}, fmt.Errorf("%w: %q", errUnexpectedField, key)
+		}
+	}
+
+	target := Target{Team: values["team"], Channel: values["channel"], Chat: values["chat"], User: values["user"]}
+	if name, ok := values["kind"]; ok {
+		kind, err := ParseKind(name)
+		if err != nil {
+			return Target{}, err
+		}
+		target.Kind = kind
+	} else {
+		target.Kind = impliedKind(target)
+	}
+	return target, target.Validate()
+}
+
+// impliedKind returns the kind whose identifiers are set in target, or 0 if ambiguous
+func impliedKind(target Target) Kind {
+	var kinds []Kind
+	if target.Team != "" || target.Channel != "" {
+		kinds = append(kinds, KindChannel)
+	}
+	if target.Chat != "" {
+		kinds = append(kinds, KindChat)
+	}
+	if target.User != "" {
+		kinds = append(kinds, KindUser)
+	}
+	if len(kinds) != 1 {
+		return 0
+	}
+	return kinds[0]
+}
+
+// Validate checks that the identifiers required by the target kind are set
+// and that no identifiers of other kinds are
+func (t Target) Validate() error {
+	identifiers := map[string]string{"team": t.Team, "channel": t.Channel, "chat": t.Chat, "user": t.User}
+	var required []string
+	switch t.Kind {
+	case KindChannel:
+		required = []string{"team", "channel"}
+	case KindChat:
+		required = []string{"chat"}
+	case KindUser:
+		required = []string{"user"}
+	case 0:
+		return errMissingKind
+	default:
+		return fmt.Errorf("%w: %d", errUnknownKind, int(t.Kind))
+	}
+
+	for _, name := range required {
+		if identifiers[name] == "" {
+			return fmt.Errorf("%w: %s target requires %s", errMissingIdentifier, t.Kind, name)
+		}
+		delete(identifiers, name)
+	}
+	var unexpected []string
+	for name, value := range identifiers {
+		if value != "" {
+			unexpected = append(unexpected, name)
+		}
+	}
+	if len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		return fmt.Errorf("%w: %s target does not take %s", errUnexpectedField, t.Kind, strings.Join(unexpected, ", "))
+	}
+
+	if t.Kind == KindUser {
+		if address, err := mail.ParseAddress(t.User); err != nil || address.Address != t.User {
+			return fmt.Errorf("%w: %q", errInvalidUser, t.User)
+		}
+	}
+	return nil
+}
+
+// String returns the target in the shorthand form accepted by ParseTarget
+func (t Target) String() string {
+	switch t.Kind {
+	case KindChannel:
+		return t.Kind.String() + ":" + t.Team + "/" + t.Channel
+	case KindChat:
+		return t.Kind.String() + ":" + t.Chat
+	default:
+		return t.Kind.String() + ":" + t.User
+	}
+}
@@ -0,0 +1,150 @@
+package recipients
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Target
+	}{
+		{"channel:Engineering/General", Target{Kind: KindChannel, Team: "Engineering", Channel: "General"}},
+		{"channel:R/D Team/Announcements", Target{Kind: KindChannel, Team: "R/D Team", Channel: "Announcements"}},
+		{"chat:19:abc123@thread.v2", Target{Kind: KindChat, Chat: "19:abc123@thread.v2"}},
+		{"user: alice@example.com", Target{Kind: KindUser, User: "alice@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTarget(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTarget() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTarget_Invalid(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr error
+	}{
+		{"alice@example.com", errMissingKind},
+		{"General", errMissingKind},
+		{"team:Engineering", errUnknownKind},
+		{"channel:General", errMissingIdentifier},
+		{"channel:Engineering/", errMissingIdentifier},
+		{"chat:", errMissingIdentifier},
+		{"user:alice", errInvalidUser},
+		{"user:Alice <alice@example.com>", errInvalidUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseTarget(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseTarget() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTarget_StringRoundTrip(t *testing.T) {
+	for _, input := range []string{"channel:R/D Team/General", "chat:19:abc@thread.v2", "user:bob@example.com"} {
+		target, err := ParseTarget(input)
+		if err != nil {
+			t.Fatalf("ParseTarget(%q) unexpected error: %v", input, err)
+		}
+		if target.String() != input {
+			t.Errorf("Target.String() = %q, want %q", target.String(), input)
+		}
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    Target
+		wantErr error
+	}{
+		{
+			name:  "shorthand",
+			value: "user:bob@example.com",
+			want:  Target{Kind: KindUser, User: "bob@example.com"},
+		},
+		{
+			name:  "map with kind",
+			value: map[string]any{"kind": "channel", "team": "Engineering", "channel": "General"},
+			want:  Target{Kind: KindChannel, Team: "Engineering", Channel: "General"},
+		},
+		{
+			name:  "implied kind",
+			value: map[string]any{"chat": "19:abc@thread.v2"},
+			want:  Target{Kind: KindChat, Chat: "19:abc@thread.v2"},
+		},
+		{
+			name:    "missing channel",
+			value:   map[string]any{"kind": "channel", "team": "Engineering"},
+			wantErr: errMissingIdentifier,
+		},
+		{
+			name:    "identifiers of another kind",
+			value:   map[string]any{"kind": "user", "user": "bob@example.com", "chat": "19:abc"},
+			wantErr: errUnexpectedField,
+		},
+		{
+			name:    "ambiguous kind",
+			value:   map[string]any{"user": "bob@example.com", "chat": "19:abc"},
+			wantErr: errMissingKind,
+		},
+		{
+			name:    "unknown field",
+			value:   map[string]any{"kind": "chat", "chat": "19:abc", "topic": "x"},
+			wantErr: errUnexpectedField,
+		},
+		{
+			name:    "unsupported value",
+			value:   42,
+			wantErr: errInvalidTargetValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromValue(tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("FromValue() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromValue() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FromValue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasKindPrefix(t *testing.T) {
+	tests := map[string]bool{
+		"user:bob@example.com": true,
+		"channel:Team/General": true,
+		"bob@example.com":      false,
+		"team:x":               false,
+		"alice":                false,
+	}
+	for input, want := range tests {
+		if got := HasKindPrefix(input); got != want {
+			t.Errorf("HasKindPrefix(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
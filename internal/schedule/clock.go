@@ -0,0 +1,24 @@
+package schedule
+
+import "time"
+
+// Clock tells the time and waits. The scheduler uses it instead of the time package,
+// so tests can replace it with a fake clock.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel receiving the time once d has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the real clock
+type SystemClock struct{}
+
+// Now implements Clock
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After implements Clock
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// SendFunc sends the messages of an item and returns the status the item ends in,
+// StatusSent or StatusFailed, with a description of the outcome
+type SendFunc func(ctx context.Context, item Item) (status, detail string)
+
+// Dispatcher sends scheduled items when they fall due
+type Dispatcher struct {
+	store *Store
+	clock Clock
+	send  SendFunc
+}
+
+// NewDispatcher returns a Dispatcher sending the items of store with send
+func NewDispatcher(store *Store, clock Clock, send SendFunc) *Dispatcher {
+	return &Dispatcher{store: store, clock: clock, send: send}
+}
+
+// RunDue sends every scheduled item that is due, earliest first, and returns how many it sent
+func (d *Dispatcher) RunDue(ctx context.Context) (int, error) {
+	items, err := d.store.List()
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	now := d.clock.Now()
+	for _, item := range items {
+		if item.Status != StatusScheduled || item.DueAt.After(now) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return dispatched, ctx.Err()
+		}
+		// another process may have cancelled or claimed the item since it was listed
+		claimed, err := d.store.Claim(item.ID)
+		if err != nil {
+			initializers.Logger.Debug("Skipping scheduled send", "id", item.ID, "error", err)
+			continue
+		}
+
+		initializers.Logger.Info("Dispatching scheduled send", "id", item.ID, "due_at", item.DueAt, "messages", len(item.Messages))
+		status, detail := d.send(ctx, claimed)
+		if err := d.store.Finish(item.ID, status, detail); err != nil {
+			return dispatched, err
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
+
+// Run sends items as they fall due until ctx is done. It looks for new items every
+// interval, and wakes up early when a known item is due sooner.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if _, err := d.RunDue(ctx); err != nil && ctx.Err() == nil {
+			initializers.Logger.Error("Failed to dispatch scheduled sends", "error", err)
+		}
+		wait := d.nextWait(interval)
+		initializers.Logger.Debug("Waiting for scheduled sends", "wait", wait)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-d.clock.After(wait):
+		}
+	}
+}
+
+// nextWait returns how long to sleep: until the next item is due, but at most interval
+func (d *Dispatcher) nextWait(interval time.Duration) time.Duration {
+	items, err := d.store.List()
+	if err != nil {
+		return interval
+	}
+	now := d.clock.Now()
+	for _, item := range items {
+		if item.Status == StatusScheduled {
+			return max(min(item.DueAt.Sub(now), interval), 0)
+		}
+	}
+	return interval
+}
@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/schedule/scheduletest"
+)
+
+// recordingSender records the IDs of the items it sends
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (r *recordingSender) send(_ context.Context, item Item) (string, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, item.ID)
+	return StatusSent, "ok"
+}
+
+func (r *recordingSender) ids() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.sent...)
+}
+
+func newTestDispatcher(t *testing.T, dueIn ...time.Duration) (*Dispatcher, *Store, *scheduletest.Clock, *recordingSender) {
+	t.Helper()
+	clock := scheduletest.NewClock(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	store := NewStore(t.TempDir())
+	for i, d := range dueIn {
+		item := Item{ID: string(rune('a' + i)), DueAt: clock.Now().Add(d), Status: StatusScheduled}
+		if err := store.Add(item); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+	}
+	sender := &recordingSender{}
+	return NewDispatcher(store, clock, sender.send), store, clock, sender
+}
+
+func TestDispatcher_RunDue(t *testing.T) {
+	dispatcher, store, clock, sender := newTestDispatcher(t, time.Hour, -time.Minute, 2*time.Hour)
+	if err := store.Cancel("c"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := dispatcher.RunDue(context.Background()); err != nil || n != 1 {
+		t.Fatalf("RunDue() = %d, %v, want 1 item sent", n, err)
+	}
+	clock.Advance(3 * time.Hour)
+	if n, err := dispatcher.RunDue(context.Background()); err != nil || n != 1 {
+		t.Fatalf("RunDue() after 3h = %d, %v, want 1 item sent", n, err)
+	}
+
+	if ids := sender.ids(); len(ids) != 2 || ids[0] != "b" || ids[1] != "a" {
+		t.Errorf("sent %v, want [b a]", ids)
+	}
+	if item, _ := store.Get("a"); item.Status != StatusSent || item.Detail != "ok" {
+		t.Errorf("item a = %+v, want sent", item)
+	}
+	if item, _ := store.Get("c"); item.Status != StatusCancelled {
+		t.Errorf("item c = %+v, want cancelled", item)
+	}
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	dispatcher, _, clock, sender := newTestDispatcher(t, 10*time.Minute, 3*time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- dispatcher.Run(ctx, time.Hour) }()
+
+	// the daemon sleeps until the first item is due
+	clock.BlockUntilWaiting(1)
+	clock.Advance(10 * time.Minute)
+	clock.BlockUntilWaiting(1)
+	if ids := sender.ids(); len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("sent %v after 10m, want [a]", ids)
+	}
+
+	// then it wakes up every interval until the second item is due
+	for range 3 {
+		clock.Advance(time.Hour)
+		clock.BlockUntilWaiting(1)
+	}
+	if ids := sender.ids(); len(ids) != 2 || ids[1] != "b" {
+		t.Errorf("sent %v after 3h10m, want [a b]", ids)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() unexpected error: %v", err)
+	}
+}
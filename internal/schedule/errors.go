@@ -0,0 +1,18 @@
+package schedule
+
+import "errors"
+
+var (
+	// Time errors
+	errInvalidTime     = errors.New("invalid time")
+	errUnknownTimeZone = errors.New("unknown time zone")
+	errTimeInPast      = errors.New("time is in the past")
+
+	// Store errors
+	errInvalidID    = errors.New("invalid schedule ID")
+	errUnknownItem  = errors.New("no scheduled send")
+	errNotScheduled = errors.New("send is no longer scheduled")
+	errStillSending = errors.New("send is still being dispatched")
+	errReadFailed   = errors.New("failed to read schedule")
+	errWriteFailed  = errors.New("failed to write schedule")
+)
@@ -0,0 +1,288 @@
+// Package schedule keeps rendered messages queued for sending at a later time
+// and dispatches them when they are due
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/filelock"
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// Statuses of a scheduled send
+const (
+	StatusScheduled = "scheduled"
+	// StatusSending is set while the send is dispatched. A send left in this status
+	// by a crash is not dispatched again until reset, after which it resumes from its journal.
+	StatusSending   = "sending"
+	StatusSent      = "sent"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// idRegex matches item IDs, which are also file names
+var idRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Message is a rendered message waiting to be sent
+type Message struct {
+	Recipient string `json:"recipient"`
+	// Target is the target in the form accepted by recipients.ParseTarget
+	Target  string `json:"target"`
+	Subject string `json:"subject,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Body    string `json:"body"`
+}
+
+// Item is a send scheduled for a point in time
+type Item struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	DueAt      time.Time `json:"due_at"`
+	Status     string    `json:"status"`
+	Template   string    `json:"template,omitempty"`
+	Importance string    `json:"importance,omitempty"`
+	// Force sends the messages even if they were sent recently
+	Force    bool      `json:"force,omitempty"`
+	Messages []Message `json:"messages"`
+	// Journal is the path of the journal recording the delivery of the messages
+	Journal string `json:"journal,omitempty"`
+	// Detail describes the outcome of the send once dispatched
+	Detail string `json:"detail,omitempty"`
+}
+
+// Store keeps scheduled sends as one JSON file per item in a directory.
+// It is safe for concurrent use, also by several processes sharing the directory.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+	// claims holds the release functions of the claim locks of items claimed by this store
+	claims map[string]func()
+}
+
+// NewStore returns the store in dir. The directory is created on the first write.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, claims: make(map[string]func())}
+}
+
+// Add stores a new item
+func (s *Store) Add(item Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.path(item.ID); err != nil {
+		return err
+	}
+	return s.write(item)
+}
+
+// Get returns the item with the given ID
+func (s *Store) Get(id string) (Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(id)
+}
+
+// List returns all items ordered by due time. Items that cannot be read are logged and left out.
+func (s *Store) List() ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errReadFailed, err)
+	}
+	var items []Item
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok || file.IsDir() {
+			continue
+		}
+		item, err := s.read(id)
+		if err != nil {
+			// one damaged file should not hide the other scheduled sends
+			initializers.Logger.Warn("Skipping unreadable scheduled send", "file", file.Name(), "error", err)
+			continue
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DueAt.Before(items[j].DueAt)
+	})
+	return items, nil
+}
+
+// Cancel cancels the item with the given ID if it is scheduled or was abandoned while sending
+func (s *Store) Cancel(id string) error {
+	_, err := s.transition(id, StatusCancelled, "", func(item Item) error {
+		if item.Status == StatusScheduled {
+			return nil
+		}
+		return s.abandoned(item)
+	})
+	return err
+}
+
+// Reset schedules the item with the given ID again if it was abandoned while sending,
+// so that it is dispatched on the next run
+func (s *Store) Reset(id string) error {
+	_, err := s.transition(id, StatusScheduled, "", s.abandoned)
+	return err
+}
+
+// Claim marks the scheduled item with the given ID as being sent and returns it.
+// Only one caller can claim an item, even across processes. The claim is held
+// until Finish is called; a claim left by a crashed process becomes stale.
+func (s *Store) Claim(id string) (Item, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Item{}, err
+	}
+	release, err := filelock.Hold(claimPath(path))
+	if err != nil {
+		return Item{}, fmt.Errorf("%w: %s is being sent: %w", errNotScheduled, id, err)
+	}
+	item, err := s.transition(id, StatusSending, "", inStatus(StatusScheduled))
+	if err != nil {
+		release()
+		return Item{}, err
+	}
+	s.mu.Lock()
+	s.claims[id] = release
+	s.mu.Unlock()
+	return item, nil
+}
+
+// Finish records the outcome of sending a claimed item and releases its claim
+func (s *Store) Finish(id, status, detail string) error {
+	_, err := s.transition(id, status, detail, inStatus(StatusSending))
+	s.mu.Lock()
+	release, ok := s.claims[id]
+	delete(s.claims, id)
+	s.mu.Unlock()
+	if ok {
+		release()
+	}
+	return err
+}
+
+// inStatus returns a transition check accepting items in the given status
+func inStatus(status string) func(Item) error {
+	return func(item Item) error {
+		if item.Status != status {
+			return fmt.Errorf("%w: %s is %s", errNotScheduled, item.ID, item.Status)
+		}
+		return nil
+	}
+}
+
+// abandoned accepts items left sending by a process that no longer holds their claim
+func (s *Store) abandoned(item Item) error {
+	if err := inStatus(StatusSending)(item); err != nil {
+		return err
+	}
+	path, err := s.path(item.ID)
+	if err != nil {
+		return err
+	}
+	if filelock.Held(claimPath(path)) {
+		return fmt.Errorf("%w: %s", errStillSending, item.ID)
+	}
+	return nil
+}
+
+// claimPath returns the path of the lock held while the item at path is sent
+func claimPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".sending"
+}
+
+// transition moves the item to status to if check accepts it. The item file is locked
+// while it is checked and rewritten, so other processes cannot move it meanwhile.
+func (s *Store) transition(id, to, detail string, check func(Item) error) (Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(id)
+	if err != nil {
+		return Item{}, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return Item{}, fmt.Errorf("%w %q", errUnknownItem, id)
+	}
+	unlock, err := filelock.Acquire(strings.TrimSuffix(path, ".json") + ".lock")
+	if err != nil {
+		return Item{}, fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	defer unlock()
+
+	item, err := s.read(id)
+	if err != nil {
+		return Item{}, err
+	}
+	if err := check(item); err != nil {
+		return Item{}, err
+	}
+	item.Status, item.Detail = to, detail
+	return item, s.write(item)
+}
+
+func (s *Store) path(id string) (string, error) {
+	if !idRegex.MatchString(id) {
+		return "", fmt.Errorf("%w %q", errInvalidID, id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// read loads an item. The caller must hold s.mu.
+func (s *Store) read(id string) (Item, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Item{}, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Item{}, fmt.Errorf("%w %q", errUnknownItem, id)
+	}
+	if err != nil {
+		return Item{}, fmt.Errorf("%w: %w", errReadFailed, err)
+	}
+	var item Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return Item{}, fmt.Errorf("%w: %s: %w", errReadFailed, id, err)
+	}
+	return item, nil
+}
+
+// write replaces the file of an item in one step. The caller must hold s.mu.
+func (s *Store) write(item Item) error {
+	path, err := s.path(item.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("%w: %w", errWriteFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package schedule
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_List(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"later", "sooner"} {
+		item := Item{ID: id, DueAt: base.Add(time.Duration(2-i) * time.Hour), Status: StatusScheduled,
+			Messages: []Message{{Recipient: "alice", Target: "user:alice@example.com", Body: "<p>Hi</p>"}}}
+		if err := store.Add(item); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "damaged.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := store.List()
+	if err != nil {
+		t.Fatalf("List() unexpected error: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != "sooner" || items[1].Messages[0].Recipient != "alice" {
+		t.Errorf("List() = %+v, want both readable items, sooner first", items)
+	}
+}
+
+func TestStore_Transitions(t *testing.T) {
+	claim := func(s *Store) error {
+		_, err := s.Claim("item")
+		return err
+	}
+	// crash leaves the item sending without a live claim, as a crashed process would
+	crash := func(s *Store) error {
+		if err := claim(s); err != nil {
+			return err
+		}
+		s.claims["item"]()
+		return nil
+	}
+	cancel := func(s *Store) error { return s.Cancel("item") }
+	reset := func(s *Store) error { return s.Reset("item") }
+	finish := func(s *Store) error { return s.Finish("item", StatusSent, "1 sent") }
+
+	tests := []struct {
+		name string
+		// before runs on a separate store, as another process would
+		before     func(*Store) error
+		transition func(*Store) error
+		wantErr    error
+		wantStatus string
+	}{
+		{name: "claim", transition: claim, wantStatus: StatusSending},
+		{name: "claim claimed", before: claim, transition: claim, wantErr: errNotScheduled, wantStatus: StatusSending},
+		{name: "claim cancelled", before: cancel, transition: claim, wantErr: errNotScheduled, wantStatus: StatusCancelled},
+		{name: "claim crashed", before: crash, transition: claim, wantErr: errNotScheduled, wantStatus: StatusSending},
+		{name: "cancel claimed", before: claim, transition: cancel, wantErr: errStillSending, wantStatus: StatusSending},
+		{name: "cancel crashed", before: crash, transition: cancel, wantStatus: StatusCancelled},
+		{name: "reset claimed", before: claim, transition: reset, wantErr: errStillSending, wantStatus: StatusSending},
+		{name: "reset crashed", before: crash, transition: reset, wantStatus: StatusScheduled},
+		{name: "reset scheduled", transition: reset, wantErr: errNotScheduled, wantStatus: StatusScheduled},
+		{name: "finish claimed", before: claim, transition: finish, wantStatus: StatusSent},
+		{name: "finish unclaimed", transition: finish, wantErr: errNotScheduled, wantStatus: StatusScheduled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := NewStore(dir).Add(Item{ID: "item", Status: StatusScheduled}); err != nil {
+				t.Fatalf("Add() unexpected error: %v", err)
+			}
+			if tt.before != nil {
+				if err := tt.before(NewStore(dir)); err != nil {
+					t.Fatalf("preparing transition unexpected error: %v", err)
+				}
+			}
+
+			store := NewStore(dir)
+			if err := tt.transition(store); !errors.Is(err, tt.wantErr) {
+				t.Errorf("transition error = %v, want %v", err, tt.wantErr)
+			}
+			if item, err := store.Get("item"); err != nil || item.Status != tt.wantStatus {
+				t.Errorf("Get() = %+v, %v, want status %s", item, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStore_Errors(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"get missing", func() error { _, err := store.Get("missing"); return err }, errUnknownItem},
+		{"claim missing", func() error { _, err := store.Claim("missing"); return err }, errUnknownItem},
+		{"add invalid ID", func() error { return store.Add(Item{ID: "../escape"}) }, errInvalidID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStore_ListEmpty(t *testing.T) {
+	items, err := NewStore(t.TempDir() + "/missing").List()
+	if err != nil || len(items) != 0 {
+		t.Errorf("List() = %v, %v, want no items", items, err)
+	}
+}
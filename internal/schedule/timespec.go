@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// localLayouts are the accepted forms of a date and time without a zone offset
+var localLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// clockLayouts are the accepted forms of a time of day
+var clockLayouts = []string{"15:04:05", "15:04"}
+
+// LoadLocation returns the time zone with the given IANA name, e.g. "Europe/Warsaw".
+// An empty name or "Local" is the system time zone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", errUnknownTimeZone, name)
+	}
+	return loc, nil
+}
+
+// ParseTime parses the time a send is scheduled for. It accepts
+//   - RFC 3339 times with a zone offset, e.g. 2026-10-19T09:00:00+02:00
+//   - a date and time in loc, e.g. "2026-10-19 09:00"
+//   - a time of day in loc, e.g. 09:00, meaning its next occurrence after now
+//
+// The time must be after now.
+func ParseTime(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	t, err := parseTime(value, now, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("%w: %s", errTimeInPast, t.Format(time.RFC3339))
+	}
+	return t, nil
+}
+
+func parseTime(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range clockLayouts {
+		clock, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		local := now.In(loc)
+		t := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+		if !t.After(now) {
+			t = time.Date(local.Year(), local.Month(), local.Day()+1, clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w %q: want RFC 3339, \"YYYY-MM-DD HH:MM\" or \"HH:MM\"", errInvalidTime, value)
+}
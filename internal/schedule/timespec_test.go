@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	warsaw, err := LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Fatalf("LoadLocation() unexpected error: %v", err)
+	}
+	// 2026-10-24 20:00 in Warsaw, the day before clocks go back from CEST (+02) to CET (+01)
+	now := time.Date(2026, 10, 24, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr error
+	}{
+		{value: "2026-10-25T09:00:00Z", want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{value: "2026-10-25T09:00:00+05:00", want: time.Date(2026, 10, 25, 4, 0, 0, 0, time.UTC)},
+		{value: "2026-10-24 21:30", want: time.Date(2026, 10, 24, 19, 30, 0, 0, time.UTC)},
+		{value: "2026-10-25T09:00", want: time.Date(2026, 10, 25, 8, 0, 0, 0, time.UTC)},
+		{value: "21:00", want: time.Date(2026, 10, 24, 19, 0, 0, 0, time.UTC)},
+		{value: "09:00", want: time.Date(2026, 10, 25, 8, 0, 0, 0, time.UTC)},
+		{value: "19:00:00", want: time.Date(2026, 10, 25, 18, 0, 0, 0, time.UTC)},
+		{value: "2026-10-24 19:00", wantErr: errTimeInPast},
+		{value: "tomorrow", wantErr: errInvalidTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTime(tt.value, now, warsaw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTime() error = %v, want %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime() = %v, want %v", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	if loc, err := LoadLocation(""); err != nil || loc != time.Local {
+		t.Errorf("LoadLocation(\"\") = %v, %v, want the local zone", loc, err)
+	}
+	if _, err := LoadLocation("Mars/Olympus"); !errors.Is(err, errUnknownTimeZone) {
+		t.Errorf("LoadLocation() error = %v, want %v", err, errUnknownTimeZone)
+	}
+}
@@ -0,0 +1,87 @@
+// Package teams delivers messages to Microsoft Teams through Microsoft Graph
+package teams
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Message importance levels understood by Teams
+const (
+	ImportanceNormal = "normal"
+	ImportanceHigh   = "high"
+	ImportanceUrgent = "urgent"
+)
+
+// Client sends messages to and lists the conversations of the signed-in user in Microsoft Teams
+type Client interface {
+	// PostChannelMessage starts a new thread in a channel of a team
+	PostChannelMessage(ctx context.Context, teamID, channelID string, msg Message) (*SentMessage, error)
+	// ReplyToMessage replies to the thread started by messageID in a channel of a team
+	ReplyToMessage(ctx context.Context, teamID, channelID, messageID string, msg Message) (*SentMessage, error)
+	// SendChatMessage sends a message to an existing chat
+	SendChatMessage(ctx context.Context, chatID string, msg Message) (*SentMessage, error)
+	// CreateOneOnOneChat returns the 1:1 chat with the user given by ID, e-mail address or UPN,
+	// creating it if it does not exist yet
+	CreateOneOnOneChat(ctx context.Context, user string) (*Chat, error)
+	// ListTeams returns the teams the signed-in user is a member of
+	ListTeams(ctx context.Context) ([]Team, error)
+	// ListChannels returns the channels of a team
+	ListChannels(ctx context.Context, teamID string) ([]Channel, error)
+	// ListChats returns the chats the signed-in user takes part in
+	ListChats(ctx context.Context) ([]Chat, error)
+}
+
+// Message is a message to send
+type Message struct {
+	// Subject is the thread subject. Teams shows it for channel posts only.
+	Subject string
+	// Body is the message content in HTML
+	Body string
+	// Summary is the preview text shown in notifications
+	Summary string
+	// Importance is normal, high or urgent; empty means normal
+	Importance string
+}
+
+// Hash returns a hash of the message content together with ids, such as the recipient
+// or target, so the same message sent elsewhere hashes differently
+func (m Message) Hash(ids ...string) string {
+	h := sha256.New()
+	for _, part := range slices.Concat(ids, []string{m.Subject, m.Summary, m.Importance, m.Body}) {
+		// length prefixes keep the parts from running into each other
+		fmt.Fprintf(h, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SentMessage describes a message accepted by Teams
+type SentMessage struct {
+	ID        string    `json:"id"`
+	WebURL    string    `json:"webUrl"`
+	CreatedAt time.Time `json:"createdDateTime"`
+}
+
+// Team is a team the signed-in user is a member of
+type Team struct {
+	ID   string `json:"id"`
+	Name string `json:"displayName"`
+}
+
+// Channel is a channel of a team
+type Channel struct {
+	ID   string `json:"id"`
+	Name string `json:"displayName"`
+}
+
+// Chat is a 1:1, group or meeting chat
+type Chat struct {
+	ID    string `json:"id"`
+	Topic string `json:"topic"`
+	// Type is oneOnOne, group or meeting
+	Type string `json:"chatType"`
+}
@@ -0,0 +1,62 @@
+package teams
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// Request errors
+	errTokenFailed     = errors.New("failed to get access token")
+	errRequestFailed   = errors.New("failed to send Graph request")
+	errEncodeFailed    = errors.New("failed to encode Graph request")
+	errDecodeFailed    = errors.New("failed to decode Graph response")
+	errGraphAPIFailure = errors.New("request to Microsoft Graph failed")
+	errForeignURL      = errors.New("refusing to send Graph request outside the base URL")
+
+	// Resolution errors
+	errUnresolvedTarget = errors.New("cannot resolve message target")
+)
+
+// APIError is an error response from Microsoft Graph
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the Graph error code, e.g. "Forbidden"
+	Code string
+	// Message describes the error
+	Message string
+	// RetryAfter is how long the server asked clients to wait before retrying, or 0
+	RetryAfter time.Duration
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	status := strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	if e.Code == "" {
+		return fmt.Sprintf("%s: %s", errGraphAPIFailure, status)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", errGraphAPIFailure, status, e.Code, e.Message)
+}
+
+// Unwrap allows matching errGraphAPIFailure
+func (e *APIError) Unwrap() error {
+	return errGraphAPIFailure
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,304 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// DefaultGraphURL is the base URL of the Microsoft Graph v1.0 API
+const DefaultGraphURL = "https://graph.microsoft.com/v1.0"
+
+// TokenSource provides access tokens for Microsoft Graph requests
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource always returning the same access token
+type StaticToken string
+
+// Token returns the token itself
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// GraphClient is a Client calling the Microsoft Graph REST API
+type GraphClient struct {
+	baseURL    string
+	tokens     TokenSource
+	httpClient *http.Client
+}
+
+var _ Client = (*GraphClient)(nil)
+
+// GraphOption configures a GraphClient
+type GraphOption func(*GraphClient)
+
+// WithBaseURL sends requests to baseURL instead of DefaultGraphURL,
+// e.g. a national cloud endpoint or a test server
+func WithBaseURL(baseURL string) GraphOption {
+	return func(c *GraphClient) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sends requests with httpClient instead of a client with a 30 second timeout
+func WithHTTPClient(httpClient *http.Client) GraphOption {
+	return func(c *GraphClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewGraphClient returns a GraphClient authenticating requests with tokens from tokens
+func NewGraphClient(tokens TokenSource, opts ...GraphOption) *GraphClient {
+	c := &GraphClient{
+		baseURL:    DefaultGraphURL,
+		tokens:     tokens,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// chatMessage is the Graph representation of a message to send
+type chatMessage struct {
+	Subject    string   `json:"subject,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Importance string   `json:"importance,omitempty"`
+	Body       itemBody `json:"body"`
+}
+
+type itemBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+func newChatMessage(msg Message, withSubject bool) chatMessage {
+	m := chatMessage{
+		Summary:    msg.Summary,
+		Importance: msg.Importance,
+		Body:       itemBody{ContentType: "html", Content: msg.Body},
+	}
+	if withSubject {
+		m.Subject = msg.Subject
+	}
+	return m
+}
+
+// PostChannelMessage implements Client
+func (c *GraphClient) PostChannelMessage(ctx context.Context, teamID, channelID string, msg Message) (*SentMessage, error) {
+	var sent SentMessage
+	path := graphPath("teams", teamID, "channels", channelID, "messages")
+	if err := c.do(ctx, http.MethodPost, path, newChatMessage(msg, true), &sent); err != nil {
+		return nil, err
+	}
+	return &sent, nil
+}
+
+// ReplyToMessage implements Client. Replies have no subject.
+func (c *GraphClient) ReplyToMessage(ctx context.Context, teamID, channelID, messageID string, msg Message) (*SentMessage, error) {
+	var sent SentMessage
+	path := graphPath("teams", teamID, "channels", channelID, "messages", messageID, "replies")
+	if err := c.do(ctx, http.MethodPost, path, newChatMessage(msg, false), &sent); err != nil {
+		return nil, err
+	}
+	return &sent, nil
+}
+
+// SendChatMessage implements Client. Chat messages have no subject.
+func (c *GraphClient) SendChatMessage(ctx context.Context, chatID string, msg Message) (*SentMessage, error) {
+	var sent SentMessage
+	if err := c.do(ctx, http.MethodPost, graphPath("chats", chatID, "messages"), newChatMessage(msg, false), &sent); err != nil {
+		return nil, err
+	}
+	return &sent, nil
+}
+
+// conversationMember is the Graph representation of a chat member to add
+type conversationMember struct {
+	Type     string   `json:"@odata.type"`
+	Roles    []string `json:"roles"`
+	UserBind string   `json:"user@odata.bind"`
+}
+
+// CreateOneOnOneChat implements Client. Graph returns the existing chat
+// if the signed-in user already has a 1:1 chat with user.
+func (c *GraphClient) CreateOneOnOneChat(ctx context.Context, user string) (*Chat, error) {
+	var me struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/me", nil, &me); err != nil {
+		return nil, err
+	}
+
+	request := struct {
+		ChatType string               `json:"chatType"`
+		Members  []conversationMember `json:"members"`
+	}{
+		ChatType: "oneOnOne",
+		Members:  []conversationMember{c.ownerMember(me.ID), c.ownerMember(user)},
+	}
+	var chat Chat
+	if err := c.do(ctx, http.MethodPost, "/chats", request, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (c *GraphClient) ownerMember(user string) conversationMember {
+	return conversationMember{
+		Type:     "#microsoft.graph.aadUserConversationMember",
+		Roles:    []string{"owner"},
+		UserBind: c.baseURL + "/users('" + strings.ReplaceAll(user, "'", "''") + "')",
+	}
+}
+
+// ListTeams implements Client
+func (c *GraphClient) ListTeams(ctx context.Context) ([]Team, error) {
+	return listAll[Team](ctx, c, "/me/joinedTeams")
+}
+
+// ListChannels implements Client
+func (c *GraphClient) ListChannels(ctx context.Context, teamID string) ([]Channel, error) {
+	return listAll[Channel](ctx, c, graphPath("teams", teamID, "channels"))
+}
+
+// ListChats implements Client
+func (c *GraphClient) ListChats(ctx context.Context) ([]Chat, error) {
+	return listAll[Chat](ctx, c, "/me/chats")
+}
+
+// page is a single page of a Graph collection
+type page[T any] struct {
+	Value    []T    `json:"value"`
+	NextLink string `json:"@odata.nextLink"`
+}
+
+// listAll returns every item of the collection at path, following next page links
+func listAll[T any](ctx context.Context, c *GraphClient, path string) ([]T, error) {
+	var items []T
+	for path != "" {
+		var p page[T]
+		if err := c.do(ctx, http.MethodGet, path, nil, &p); err != nil {
+			return nil, err
+		}
+		items = append(items, p.Value...)
+		path = p.NextLink
+	}
+	return items, nil
+}
+
+// graphPath joins escaped path segments into a request path
+func graphPath(segments ...string) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString("/" + url.PathEscape(segment))
+	}
+	return b.String()
+}
+
+// do sends a request with body encoded as JSON and decodes the response into result.
+// path is relative to the base URL unless it is an absolute URL, such as a next page link.
+// Error responses are returned as *APIError.
+func (c *GraphClient) do(ctx context.Context, method, path string, body, result any) error {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errTokenFailed, err)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errEncodeFailed, err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	target, err := c.requestURL(path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRequestFailed, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	initializers.Logger.Debug("Sending Graph request", "method", method, "url", target)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRequestFailed, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			initializers.Logger.Warn("Failed to close Graph response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := newAPIError(resp)
+		initializers.Logger.Debug("Graph request failed", "method", method, "url", target, "status", resp.StatusCode, "code", apiErr.Code)
+		return apiErr
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("%w: %w", errDecodeFailed, err)
+	}
+	return nil
+}
+
+// requestURL resolves path against the base URL. Absolute URLs, such as next page
+// links, are only accepted on the scheme and host of the base URL, so the access
+// token is never sent elsewhere.
+func (c *GraphClient) requestURL(path string) (string, error) {
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		return c.baseURL + path, nil
+	}
+	target, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errRequestFailed, err)
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errRequestFailed, err)
+	}
+	if !strings.EqualFold(target.Scheme, base.Scheme) || !strings.EqualFold(target.Host, base.Host) {
+		return "", fmt.Errorf("%w: %s", errForeignURL, target.Redacted())
+	}
+	return path, nil
+}
+
+// newAPIError reads the Graph error from an error response
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err == nil {
+		apiErr.Code, apiErr.Message = body.Error.Code, body.Error.Message
+	}
+	return apiErr
+}
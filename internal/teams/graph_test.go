@@ -0,0 +1,210 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/teams/teamstest"
+)
+
+func newTestClient(t *testing.T) (*GraphClient, *teamstest.Server) {
+	t.Helper()
+	server := teamstest.NewServer(t)
+	server.Token = "secret"
+	return NewGraphClient(StaticToken("secret"), WithBaseURL(server.URL)), server
+}
+
+func TestGraphClient_PostChannelMessage(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "19:general@thread.tacv2", Name: "General"})
+
+	msg := Message{Subject: "Release", Body: "<p>Shipped</p>", Summary: "Shipped", Importance: ImportanceHigh}
+	sent, err := client.PostChannelMessage(context.Background(), "team-1", "19:general@thread.tacv2", msg)
+	if err != nil {
+		t.Fatalf("PostChannelMessage() unexpected error: %v", err)
+	}
+	if sent.ID == "" || sent.CreatedAt.IsZero() {
+		t.Errorf("PostChannelMessage() = %+v, want ID and creation time", sent)
+	}
+
+	want := teamstest.PostedMessage{
+		TeamID: "team-1", ChannelID: "19:general@thread.tacv2", ID: sent.ID, Subject: "Release",
+		Summary: "Shipped", Importance: ImportanceHigh, ContentType: "html", Content: "<p>Shipped</p>",
+	}
+	if messages := server.Messages(); len(messages) != 1 || messages[0] != want {
+		t.Errorf("server received %+v, want %+v", messages, want)
+	}
+}
+
+func TestGraphClient_ReplyAndChatMessagesHaveNoSubject(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "general", Name: "General"})
+	server.AddChat("19:chat@thread.v2", "Project", "group")
+	ctx := context.Background()
+	msg := Message{Subject: "Ignored", Body: "Hi"}
+
+	if _, err := client.ReplyToMessage(ctx, "team-1", "general", "42", msg); err != nil {
+		t.Fatalf("ReplyToMessage() unexpected error: %v", err)
+	}
+	if _, err := client.SendChatMessage(ctx, "19:chat@thread.v2", msg); err != nil {
+		t.Fatalf("SendChatMessage() unexpected error: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("server received %d messages, want 2", len(messages))
+	}
+	if messages[0].ReplyTo != "42" || messages[1].ChatID != "19:chat@thread.v2" {
+		t.Errorf("server received %+v", messages)
+	}
+	for _, message := range messages {
+		if message.Subject != "" {
+			t.Errorf("message %+v has a subject, want none", message)
+		}
+	}
+}
+
+func TestGraphClient_CreateOneOnOneChat(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddUser("user-2", "o'brien@example.com")
+	ctx := context.Background()
+
+	chat, err := client.CreateOneOnOneChat(ctx, "o'brien@example.com")
+	if err != nil {
+		t.Fatalf("CreateOneOnOneChat() unexpected error: %v", err)
+	}
+	again, err := client.CreateOneOnOneChat(ctx, "user-2")
+	if err != nil {
+		t.Fatalf("CreateOneOnOneChat() unexpected error: %v", err)
+	}
+	if chat.ID == "" || chat.Type != "oneOnOne" || again.ID != chat.ID {
+		t.Errorf("CreateOneOnOneChat() = %+v then %+v, want the same 1:1 chat", chat, again)
+	}
+
+	if _, err := client.SendChatMessage(ctx, chat.ID, Message{Body: "Hi"}); err != nil {
+		t.Errorf("SendChatMessage() to created chat unexpected error: %v", err)
+	}
+}
+
+func TestGraphClient_ListFollowsPages(t *testing.T) {
+	client, server := newTestClient(t)
+	server.PageSize = 2
+	server.AddTeam("team-1", "Engineering",
+		teamstest.Channel{ID: "c1", Name: "General"}, teamstest.Channel{ID: "c2", Name: "Random"}, teamstest.Channel{ID: "c3", Name: "Ops"})
+	server.AddTeam("team-2", "Sales")
+	server.AddTeam("team-3", "Support")
+	server.AddChat("19:a@thread.v2", "A", "group")
+	ctx := context.Background()
+
+	teams, err := client.ListTeams(ctx)
+	if err != nil {
+		t.Fatalf("ListTeams() unexpected error: %v", err)
+	}
+	if len(teams) != 3 || teams[2] != (Team{ID: "team-3", Name: "Support"}) {
+		t.Errorf("ListTeams() = %+v", teams)
+	}
+
+	channels, err := client.ListChannels(ctx, "team-1")
+	if err != nil {
+		t.Fatalf("ListChannels() unexpected error: %v", err)
+	}
+	if len(channels) != 3 || channels[2] != (Channel{ID: "c3", Name: "Ops"}) {
+		t.Errorf("ListChannels() = %+v", channels)
+	}
+
+	chats, err := client.ListChats(ctx)
+	if err != nil {
+		t.Fatalf("ListChats() unexpected error: %v", err)
+	}
+	if len(chats) != 1 || chats[0] != (Chat{ID: "19:a@thread.v2", Topic: "A", Type: "group"}) {
+		t.Errorf("ListChats() = %+v", chats)
+	}
+}
+
+func TestGraphClient_ListRejectsForeignNextLink(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request sent to foreign host with Authorization %q", r.Header.Get("Authorization"))
+	}))
+	defer foreign.Close()
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value": [], "@odata.nextLink": "` + foreign.URL + `/v1.0/me/chats?$skiptoken=1"}`))
+	}))
+	defer graph.Close()
+	client := NewGraphClient(StaticToken("secret"), WithBaseURL(graph.URL))
+
+	if _, err := client.ListChats(context.Background()); !errors.Is(err, errForeignURL) {
+		t.Errorf("ListChats() error = %v, want %v", err, errForeignURL)
+	}
+}
+
+func TestGraphClient_APIError(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddChat("19:chat@thread.v2", "Project", "group")
+	server.Fail(1, http.StatusTooManyRequests, "TooManyRequests", 7*time.Second)
+
+	_, err := client.SendChatMessage(context.Background(), "19:chat@thread.v2", Message{Body: "Hi"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("SendChatMessage() error = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != "TooManyRequests" || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("SendChatMessage() error = %+v", apiErr)
+	}
+	if !errors.Is(err, errGraphAPIFailure) {
+		t.Errorf("SendChatMessage() error = %v, want it to match %v", err, errGraphAPIFailure)
+	}
+}
+
+func TestGraphClient_NotFoundAndUnauthorized(t *testing.T) {
+	client, server := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.PostChannelMessage(ctx, "missing", "general", Message{Body: "Hi"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("PostChannelMessage() error = %v, want 404", err)
+	}
+
+	unauthorized := NewGraphClient(StaticToken("wrong"), WithBaseURL(server.URL))
+	_, err = unauthorized.ListTeams(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("ListTeams() error = %v, want 401", err)
+	}
+}
+
+type failingTokens struct{}
+
+func (failingTokens) Token(context.Context) (string, error) {
+	return "", errors.New("not signed in")
+}
+
+func TestGraphClient_TokenFailure(t *testing.T) {
+	client := NewGraphClient(failingTokens{}, WithBaseURL("http://127.0.0.1:0"))
+
+	if _, err := client.ListTeams(context.Background()); !errors.Is(err, errTokenFailed) {
+		t.Errorf("ListTeams() error = %v, want %v", err, errTokenFailed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-1":                            0,
+		"soon":                          0,
+		"Fri, 02 Jan 2026 15:04:35 GMT": 30 * time.Second,
+		"Fri, 02 Jan 2026 15:00:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+
+	"github.com/pzsp-teams/cli/internal/recipients"
+)
+
+// Destination is the conversation a message is posted to: a channel of a team or a chat
+type Destination struct {
+	TeamID    string
+	ChannelID string
+	ChatID    string
+}
+
+// Resolver maps recipient targets to destinations. Teams and channels are matched by
+// ID or case-insensitively by name, and users are mapped to their 1:1 chats.
+// Lookups are cached, so a Resolver should be used for a single send.
+// It is safe for concurrent use: lookups of different keys run in parallel,
+// and concurrent lookups of the same key share a single request.
+type Resolver struct {
+	client Client
+
+	mu       sync.Mutex
+	teams    []Team
+	channels map[string][]Channel
+	chats    map[string]string
+	// lookups holds the requests in flight by cache key
+	lookups map[string]*lookup
+}
+
+// lookup is a request in flight whose result is shared by the callers asking for its key
+type lookup struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// NewResolver returns a Resolver looking up conversations with client
+func NewResolver(client Client) *Resolver {
+	return &Resolver{
+		client:   client,
+		channels: make(map[string][]Channel),
+		chats:    make(map[string]string),
+		lookups:  make(map[string]*lookup),
+	}
+}
+
+// Resolve returns the destination of target
+func (r *Resolver) Resolve(ctx context.Context, target recipients.Target) (Destination, error) {
+	switch target.Kind {
+	case recipients.KindChannel:
+		return r.resolveChannel(ctx, target.Team, target.Channel)
+	case recipients.KindChat:
+		return Destination{ChatID: target.Chat}, nil
+	case recipients.KindUser:
+		chatID, err := cachedLookup(ctx, r, "user:"+target.User, r.chats, target.User, func() (string, error) {
+			chat, err := r.client.CreateOneOnOneChat(ctx, target.User)
+			if err != nil {
+				return "", err
+			}
+			return chat.ID, nil
+		})
+		return Destination{ChatID: chatID}, err
+	default:
+		return Destination{}, fmt.Errorf("%w: %s", errUnresolvedTarget, target)
+	}
+}
+
+func (r *Resolver) resolveChannel(ctx context.Context, teamName, channelName string) (Destination, error) {
+	teams, err := r.listTeams(ctx)
+	if err != nil {
+		return Destination{}, err
+	}
+	team, err := findByName(teams, teamName, "team", func(t Team) (string, string) { return t.ID, t.Name })
+	if err != nil {
+		return Destination{}, err
+	}
+
+	channels, err := cachedLookup(ctx, r, "channels:"+team.ID, r.channels, team.ID, func() ([]Channel, error) {
+		return r.client.ListChannels(ctx, team.ID)
+	})
+	if err != nil {
+		return Destination{}, err
+	}
+	channel, err := findByName(channels, channelName, "channel", func(c Channel) (string, string) { return c.ID, c.Name })
+	if err != nil {
+		return Destination{}, fmt.Errorf("%w in team %q", err, team.Name)
+	}
+	return Destination{TeamID: team.ID, ChannelID: channel.ID}, nil
+}
+
+// listTeams returns the teams of the signed-in user, listing them on first use
+func (r *Resolver) listTeams(ctx context.Context) ([]Team, error) {
+	r.mu.Lock()
+	teams := r.teams
+	r.mu.Unlock()
+	if teams != nil {
+		return teams, nil
+	}
+	return shareLookup(ctx, r, "teams", func() ([]Team, error) {
+		teams, err := r.client.ListTeams(ctx)
+		if err == nil {
+			r.mu.Lock()
+			r.teams = teams
+			r.mu.Unlock()
+		}
+		return teams, err
+	})
+}
+
+// cachedLookup returns cache[key], calling fetch to fill it on a miss.
+// Concurrent misses of the same key share one call, identified by lookupKey.
+func cachedLookup[T any](ctx context.Context, r *Resolver, lookupKey string, cache map[string]T, key string, fetch func() (T, error)) (T, error) {
+	r.mu.Lock()
+	value, ok := cache[key]
+	r.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	return shareLookup(ctx, r, lookupKey, func() (T, error) {
+		value, err := fetch()
+		if err == nil {
+			r.mu.Lock()
+			cache[key] = value
+			r.mu.Unlock()
+		}
+		return value, err
+	})
+}
+
+// shareLookup calls fetch unless a call for key is already in flight, in which
+// case it waits for that call and returns its result. r.mu is not held while fetching,
+// so lookups of other keys go ahead meanwhile. Failed results are not kept.
+func shareLookup[T any](ctx context.Context, r *Resolver, key string, fetch func() (T, error)) (T, error) {
+	var zero T
+	r.mu.Lock()
+	if l, ok := r.lookups[key]; ok {
+		r.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-l.done:
+		}
+		if l.err != nil {
+			return zero, l.err
+		}
+		return l.value.(T), nil
+	}
+	l := &lookup{done: make(chan struct{})}
+	r.lookups[key] = l
+	r.mu.Unlock()
+
+	value, err := fetch()
+	l.value, l.err = value, err
+	r.mu.Lock()
+	delete(r.lookups, key)
+	r.mu.Unlock()
+	close(l.done)
+	return value, err
+}
+
+// findByName returns the item whose ID is key or, failing that, the only item named key
+func findByName[T any](items []T, key, kind string, fields func(T) (id, name string)) (T, error) {
+	var matches []T
+	for _, item := range items {
+		id, name := fields(item)
+		if id == key {
+			return item, nil
+		}
+		if strings.EqualFold(name, key) {
+			matches = append(matches, item)
+		}
+	}
+
+	var zero T
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return zero, fmt.Errorf("%w: no %s named %q", errUnresolvedTarget, kind, key)
+	default:
+		return zero, fmt.Errorf("%w: %d %ss named %q, use the ID instead", errUnresolvedTarget, len(matches), kind, key)
+	}
+}
+
+// ChatBody returns the body posted to a chat for a message with subject and body.
+// Chats have no subject line, so a subject becomes the bold first line of the body instead.
+func ChatBody(subject, body string) string {
+	if subject == "" {
+		return body
+	}
+	return "<p><b>" + html.EscapeString(subject) + "</b></p>" + body
+}
+
+// Send posts msg to dest, composing chat messages with ChatBody
+func Send(ctx context.Context, client Client, dest Destination, msg Message) (*SentMessage, error) {
+	if dest.ChatID != "" {
+		msg.Body = ChatBody(msg.Subject, msg.Body)
+		return client.SendChatMessage(ctx, dest.ChatID, msg)
+	}
+	return client.PostChannelMessage(ctx, dest.TeamID, dest.ChannelID, msg)
+}
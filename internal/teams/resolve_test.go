@@ -0,0 +1,147 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pzsp-teams/cli/internal/recipients"
+	"github.com/pzsp-teams/cli/internal/teams/teamstest"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "c1", Name: "General"}, teamstest.Channel{ID: "c2", Name: "Random"})
+	server.AddTeam("team-2", "Sales", teamstest.Channel{ID: "c3", Name: "General"})
+	server.AddUser("user-2", "bob@example.com")
+	resolver := NewResolver(client)
+
+	tests := []struct {
+		target string
+		want   Destination
+	}{
+		{"channel:Engineering/General", Destination{TeamID: "team-1", ChannelID: "c1"}},
+		{"channel:engineering/random", Destination{TeamID: "team-1", ChannelID: "c2"}},
+		{"channel:team-2/c3", Destination{TeamID: "team-2", ChannelID: "c3"}},
+		{"chat:19:abc@thread.v2", Destination{ChatID: "19:abc@thread.v2"}},
+		{"user:bob@example.com", Destination{ChatID: "19:00000000-0000-0000-0000-000000000001_user-2@unq.gbl.spaces"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			target, err := recipients.ParseTarget(tt.target)
+			if err != nil {
+				t.Fatalf("ParseTarget() unexpected error: %v", err)
+			}
+			got, err := resolver.Resolve(context.Background(), target)
+			if err != nil {
+				t.Fatalf("Resolver.Resolve() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolver.Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	requests := server.Requests()
+	target, _ := recipients.ParseTarget("channel:Engineering/Random")
+	if _, err := resolver.Resolve(context.Background(), target); err != nil {
+		t.Fatalf("Resolver.Resolve() unexpected error: %v", err)
+	}
+	if server.Requests() != requests {
+		t.Errorf("Resolver.Resolve() sent %d requests for a cached channel, want none", server.Requests()-requests)
+	}
+}
+
+// blockingClient creates 1:1 chats only once released, reporting each request as it starts
+type blockingClient struct {
+	Client
+	mu      sync.Mutex
+	calls   map[string]int
+	started chan string
+	release chan struct{}
+}
+
+func (c *blockingClient) CreateOneOnOneChat(_ context.Context, user string) (*Chat, error) {
+	c.mu.Lock()
+	c.calls[user]++
+	c.mu.Unlock()
+	c.started <- user
+	<-c.release
+	return &Chat{ID: "chat-" + user}, nil
+}
+
+func TestResolver_ConcurrentLookups(t *testing.T) {
+	client := &blockingClient{calls: make(map[string]int), started: make(chan string, 3), release: make(chan struct{})}
+	resolver := NewResolver(client)
+
+	users := []string{"bob@example.com", "bob@example.com", "carol@example.com"}
+	results := make([]Destination, len(users))
+	var wg sync.WaitGroup
+	for i, user := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = resolver.Resolve(context.Background(), recipients.Target{Kind: recipients.KindUser, User: user})
+		}()
+	}
+
+	// both users are looked up at the same time, not one after the other
+	started := map[string]bool{}
+	for range 2 {
+		select {
+		case user := <-client.started:
+			started[user] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("lookups started = %v, want both users looked up in parallel", started)
+		}
+	}
+	close(client.release)
+	wg.Wait()
+
+	if !started["bob@example.com"] || !started["carol@example.com"] || client.calls["bob@example.com"] != 1 {
+		t.Errorf("lookups = %v, want one per user", client.calls)
+	}
+	if results[0].ChatID != "chat-bob@example.com" || results[1] != results[0] || results[2].ChatID != "chat-carol@example.com" {
+		t.Errorf("Resolver.Resolve() = %+v, want each user's chat", results)
+	}
+}
+
+func TestResolver_Unresolved(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "c1", Name: "General"})
+	server.AddTeam("team-2", "engineering")
+	resolver := NewResolver(client)
+
+	for _, input := range []string{"channel:Marketing/General", "channel:team-1/Random", "channel:Engineering/General"} {
+		target, _ := recipients.ParseTarget(input)
+		if _, err := resolver.Resolve(context.Background(), target); !errors.Is(err, errUnresolvedTarget) {
+			t.Errorf("Resolver.Resolve(%s) error = %v, want %v", input, err, errUnresolvedTarget)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	client, server := newTestClient(t)
+	server.AddTeam("team-1", "Engineering", teamstest.Channel{ID: "c1", Name: "General"})
+	server.AddChat("19:chat@thread.v2", "Project", "group")
+	msg := Message{Subject: "Q&A", Body: "<p>Hi</p>"}
+	ctx := context.Background()
+
+	if _, err := Send(ctx, client, Destination{TeamID: "team-1", ChannelID: "c1"}, msg); err != nil {
+		t.Fatalf("Send() to channel unexpected error: %v", err)
+	}
+	if _, err := Send(ctx, client, Destination{ChatID: "19:chat@thread.v2"}, msg); err != nil {
+		t.Fatalf("Send() to chat unexpected error: %v", err)
+	}
+
+	messages := server.Messages()
+	if messages[0].Subject != "Q&A" || messages[0].Content != "<p>Hi</p>" {
+		t.Errorf("channel message = %+v", messages[0])
+	}
+	if messages[1].Subject != "" || messages[1].Content != "<p><b>Q&amp;A</b></p><p>Hi</p>" {
+		t.Errorf("chat message = %+v, want the subject in the body", messages[1])
+	}
+}
@@ -0,0 +1,372 @@
+// Package teamstest provides an in-memory Microsoft Graph server for testing Teams clients offline
+package teamstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// userBindRegex extracts the user from a member binding such as https://graph/users('alice@example.com')
+var userBindRegex = regexp.MustCompile(`/users\('((?:[^']|'')*)'\)$`)
+
+// Channel is a channel of a team held by the server
+type Channel struct {
+	ID   string
+	Name string
+}
+
+// PostedMessage is a message received by the server
+type PostedMessage struct {
+	TeamID    string
+	ChannelID string
+	ChatID    string
+	// ReplyTo is the ID of the message replied to, or empty for new threads and chat messages
+	ReplyTo string
+	// ID is the ID assigned to the message
+	ID          string
+	Subject     string
+	Summary     string
+	Importance  string
+	ContentType string
+	Content     string
+}
+
+// Server is an httptest server implementing the parts of Microsoft Graph used to send
+// Teams messages. It holds teams, channels, chats and users in memory and records every
+// message posted to it. Clients should use URL as their Graph base URL.
+type Server struct {
+	*httptest.Server
+
+	// Token is the bearer token requests must carry, or empty to accept any
+	Token string
+	// PageSize is the number of items returned per page of collections, or 0 for all
+	PageSize int
+
+	mu       sync.Mutex
+	me       user
+	users    []user
+	teams    []team
+	chats    []chat
+	messages []PostedMessage
+	failures []failure
+	requests int
+}
+
+type user struct {
+	id, upn string
+}
+
+type team struct {
+	id, name string
+	channels []Channel
+}
+
+type chat struct {
+	id, topic, chatType string
+	// with is the other member of a oneOnOne chat
+	with string
+}
+
+type failure struct {
+	status     int
+	code       string
+	retryAfter time.Duration
+}
+
+// NewServer starts a server with the signed-in user "me@example.com".
+// The server is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{me: user{id: "00000000-0000-0000-0000-000000000001", upn: "me@example.com"}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /me", s.handleMe)
+	mux.HandleFunc("GET /me/joinedTeams", s.handleListTeams)
+	mux.HandleFunc("GET /me/chats", s.handleListChats)
+	mux.HandleFunc("GET /teams/{team}/channels", s.handleListChannels)
+	mux.HandleFunc("POST /teams/{team}/channels/{channel}/messages", s.handlePostMessage)
+	mux.HandleFunc("POST /teams/{team}/channels/{channel}/messages/{message}/replies", s.handlePostMessage)
+	mux.HandleFunc("POST /chats/{chat}/messages", s.handlePostMessage)
+	mux.HandleFunc("POST /chats", s.handleCreateChat)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// AddTeam adds a team with the given channels
+func (s *Server) AddTeam(id, name string, channels ...Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teams = append(s.teams, team{id: id, name: name, channels: channels})
+}
+
+// AddChat adds a chat of the given type: oneOnOne, group or meeting
+func (s *Server) AddChat(id, topic, chatType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chats = append(s.chats, chat{id: id, topic: topic, chatType: chatType})
+}
+
+// AddUser adds a user that 1:1 chats can be created with
+func (s *Server) AddUser(id, upn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, user{id: id, upn: upn})
+}
+
+// Fail makes the next times requests fail with status and the Graph error code,
+// asking clients to retry after retryAfter if it is positive
+func (s *Server) Fail(times, status int, code string, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range times {
+		s.failures = append(s.failures, failure{status: status, code: code, retryAfter: retryAfter})
+	}
+}
+
+// Messages returns the messages posted so far, in the order they were received
+func (s *Server) Messages() []PostedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]PostedMessage(nil), s.messages...)
+}
+
+// Requests returns the number of requests received so far, including failed ones
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// middleware counts requests, checks the token and injects failures
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		var injected *failure
+		if len(s.failures) > 0 {
+			injected = &s.failures[0]
+			s.failures = s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+			writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is missing or invalid.")
+			return
+		}
+		if injected != nil {
+			if injected.retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(injected.retryAfter.Seconds())))
+			}
+			writeError(w, injected.status, injected.code, "Injected failure.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"id": s.me.id, "userPrincipalName": s.me.upn})
+}
+
+func (s *Server) handleListTeams(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	items := make([]any, len(s.teams))
+	for i, t := range s.teams {
+		items[i] = map[string]string{"id": t.id, "displayName": t.name}
+	}
+	s.mu.Unlock()
+	s.writePage(w, r, items)
+}
+
+func (s *Server) handleListChannels(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.findTeam(r.PathValue("team"))
+	var items []any
+	if ok {
+		for _, c := range t.channels {
+			items = append(items, map[string]string{"id": c.ID, "displayName": c.Name})
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", "Team not found.")
+		return
+	}
+	s.writePage(w, r, items)
+}
+
+func (s *Server) handleListChats(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	items := make([]any, len(s.chats))
+	for i, c := range s.chats {
+		items[i] = map[string]string{"id": c.id, "topic": c.topic, "chatType": c.chatType}
+	}
+	s.mu.Unlock()
+	s.writePage(w, r, items)
+}
+
+func (s *Server) handlePostMessage(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Subject    string `json:"subject"`
+		Summary    string `json:"summary"`
+		Importance string `json:"importance"`
+		Body       struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		} `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Body.Content == "" {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Message body is missing.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message := PostedMessage{
+		TeamID:      r.PathValue("team"),
+		ChannelID:   r.PathValue("channel"),
+		ChatID:      r.PathValue("chat"),
+		ReplyTo:     r.PathValue("message"),
+		ID:          strconv.Itoa(len(s.messages) + 1),
+		Subject:     body.Subject,
+		Summary:     body.Summary,
+		Importance:  body.Importance,
+		ContentType: body.Body.ContentType,
+		Content:     body.Body.Content,
+	}
+	if !s.conversationExists(message) {
+		writeError(w, http.StatusNotFound, "NotFound", "Conversation not found.")
+		return
+	}
+	s.messages = append(s.messages, message)
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"id":              message.ID,
+		"webUrl":          s.URL + "/messages/" + message.ID,
+		"createdDateTime": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *Server) handleCreateChat(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ChatType string `json:"chatType"`
+		Members  []struct {
+			UserBind string `json:"user@odata.bind"`
+		} `json:"members"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ChatType != "oneOnOne" || len(body.Members) != 2 {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Only oneOnOne chats with two members can be created.")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var other *user
+	for _, member := range body.Members {
+		m := userBindRegex.FindStringSubmatch(member.UserBind)
+		if m == nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", "Invalid member binding.")
+			return
+		}
+		u, ok := s.findUser(strings.ReplaceAll(m[1], "''", "'"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("User %s not found.", m[1]))
+			return
+		}
+		if u.id != s.me.id {
+			other = &u
+		}
+	}
+	if other == nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", "A oneOnOne chat needs another member.")
+		return
+	}
+
+	for _, c := range s.chats {
+		if c.chatType == "oneOnOne" && c.with == other.id {
+			writeJSON(w, http.StatusOK, map[string]string{"id": c.id, "chatType": c.chatType})
+			return
+		}
+	}
+	created := chat{id: "19:" + s.me.id + "_" + other.id + "@unq.gbl.spaces", chatType: "oneOnOne", with: other.id}
+	s.chats = append(s.chats, created)
+	writeJSON(w, http.StatusCreated, map[string]string{"id": created.id, "chatType": created.chatType})
+}
+
+// findTeam returns the team with the given ID; s.mu must be held
+func (s *Server) findTeam(id string) (team, bool) {
+	for _, t := range s.teams {
+		if t.id == id {
+			return t, true
+		}
+	}
+	return team{}, false
+}
+
+// findUser returns the user with the given ID or UPN, including the signed-in user; s.mu must be held
+func (s *Server) findUser(idOrUPN string) (user, bool) {
+	for _, u := range append([]user{s.me}, s.users...) {
+		if u.id == idOrUPN || u.upn == idOrUPN {
+			return u, true
+		}
+	}
+	return user{}, false
+}
+
+// conversationExists reports whether the channel or chat a message is posted to exists; s.mu must be held
+func (s *Server) conversationExists(message PostedMessage) bool {
+	if message.ChatID != "" {
+		for _, c := range s.chats {
+			if c.id == message.ChatID {
+				return true
+			}
+		}
+		return false
+	}
+	t, ok := s.findTeam(message.TeamID)
+	if !ok {
+		return false
+	}
+	for _, c := range t.channels {
+		if c.ID == message.ChannelID {
+			return true
+		}
+	}
+	return false
+}
+
+// writePage writes the page of items selected by the $skiptoken query parameter
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []any) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+	start = min(max(start, 0), len(items))
+	end := len(items)
+	if s.PageSize > 0 {
+		end = min(start+s.PageSize, len(items))
+	}
+
+	response := map[string]any{"value": append([]any{}, items[start:end]...)}
+	if end < len(items) {
+		response["@odata.nextLink"] = fmt.Sprintf("%s%s?$skiptoken=%d", s.URL, r.URL.EscapedPath(), end)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]string{"code": code, "message": message}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// utf8BOM is prepended to CSV files exported by some spreadsheet applications
+const utf8BOM = "\ufeff"
+
+// CSVParser implements Parser for delimiter-separated values.
+// The header row provides placeholder names and one column holds recipient names.
+type CSVParser struct {
+	// KeyColumn is the header of the column holding recipient names.
+	// If empty, the first column is used.
+	KeyColumn string
+	// Comma is the field delimiter. If zero, ',' is used.
+	Comma rune
+}
+
+// NewTSVParser returns a CSVParser for tab-separated values
+func NewTSVParser() *CSVParser {
+	return &CSVParser{Comma: '\t'}
+}
+
+// Parse reads CSV-formatted message data
+func (p *CSVParser) Parse(r io.Reader) ([]Recipient, error) {
+	reader := csv.NewReader(r)
+	if p.Comma != 0 {
+		reader.Comma = p.Comma
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, p.decodeError(err)
+	}
+
+	keyIndex, err := p.keyColumnIndex(header)
+	if err != nil {
+		return nil, p.decodeError(err)
+	}
+
+	var recipients []Recipient
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, p.decodeError(err)
+		}
+
+		line, column := reader.FieldPos(keyIndex)
+		recipient := strings.TrimSpace(record[keyIndex])
+		if recipient == "" {
+			return nil, p.decodeError(fmt.Errorf("%w: row %d, column %d", errCSVEmptyKey, line, column))
+		}
+		if seen[recipient] {
+			return nil, p.decodeError(fmt.Errorf("%w %q: row %d, column %d", errDuplicateRecipient, recipient, line, column))
+		}
+		seen[recipient] = true
+
+		data := make(TemplateData, len(header))
+		for i, name := range header {
+			data[name] = record[i]
+		}
+		recipients = append(recipients, Recipient{Name: recipient, Data: data})
+	}
+
+	return recipients, nil
+}
+
+// keyColumnIndex normalizes header names in place and returns the index of the recipient column
+func (p *CSVParser) keyColumnIndex(header []string) (int, error) {
+	header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	if p.KeyColumn == "" {
+		return 0, nil
+	}
+	for i, name := range header {
+		if name == p.KeyColumn {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", errCSVKeyColumnMissing, p.KeyColumn)
+}
+
+func (p *CSVParser) decodeError(err error) error {
+	initializers.Logger.Error(errCSVDecodeFailed.Error(), "error", err)
+	return fmt.Errorf("%w: %w", errCSVDecodeFailed, err)
+}
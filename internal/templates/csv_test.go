@@ -0,0 +1,161 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCSVParser_HeaderBecomesPlaceholders(t *testing.T) {
+	data := "recipient,name,order\nalice,Alice,12345\nbob,Bob,67890\n"
+
+	messages, err := parseRecipients(&CSVParser{}, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("CSVParser.Parse() unexpected error: %v", err)
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("CSVParser.Parse() got %d messages, want 2", len(messages))
+	}
+	if messages["alice"]["name"] != "Alice" || messages["alice"]["order"] != "12345" {
+		t.Errorf("CSVParser.Parse() alice = %v", messages["alice"])
+	}
+	if messages["bob"]["recipient"] != "bob" {
+		t.Errorf("CSVParser.Parse() bob recipient = %q, want %q", messages["bob"]["recipient"], "bob")
+	}
+}
+
+func TestCSVParser_KeyColumn(t *testing.T) {
+	data := "name,channel\nAlice,general\nBob,random\n"
+
+	messages, err := parseRecipients(&CSVParser{KeyColumn: "channel"}, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("CSVParser.Parse() unexpected error: %v", err)
+	}
+
+	if messages["general"]["name"] != "Alice" {
+		t.Errorf("CSVParser.Parse() general name = %q, want %q", messages["general"]["name"], "Alice")
+	}
+	if messages["random"]["name"] != "Bob" {
+		t.Errorf("CSVParser.Parse() random name = %q, want %q", messages["random"]["name"], "Bob")
+	}
+}
+
+func TestCSVParser_TSV(t *testing.T) {
+	data := "recipient\tname\nalice\tAlice, PhD\n"
+
+	messages, err := parseRecipients(NewTSVParser(), strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("CSVParser.Parse() unexpected error: %v", err)
+	}
+
+	if messages["alice"]["name"] != "Alice, PhD" {
+		t.Errorf("CSVParser.Parse() alice name = %q, want %q", messages["alice"]["name"], "Alice, PhD")
+	}
+}
+
+func TestCSVParser_ByteOrderMark(t *testing.T) {
+	data := "\ufeffrecipient, name \nalice,Alice\n"
+
+	messages, err := parseRecipients(&CSVParser{KeyColumn: "recipient"}, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("CSVParser.Parse() unexpected error: %v", err)
+	}
+
+	if messages["alice"]["name"] != "Alice" {
+		t.Errorf("CSVParser.Parse() alice name = %q, want %q", messages["alice"]["name"], "Alice")
+	}
+}
+
+func TestCSVParser_Empty(t *testing.T) {
+	messages, err := parseRecipients(&CSVParser{}, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("CSVParser.Parse() unexpected error: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("CSVParser.Parse() got %d messages, want 0", len(messages))
+	}
+}
+
+func TestCSVParser_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  *CSVParser
+		data    string
+		wantErr error
+		wantPos string
+	}{
+		{
+			name:    "missing key column",
+			parser:  &CSVParser{KeyColumn: "email"},
+			data:    "recipient,name\nalice,Alice\n",
+			wantErr: errCSVKeyColumnMissing,
+		},
+		{
+			name:    "empty key",
+			parser:  &CSVParser{},
+			data:    "recipient,name\nalice,Alice\n,Bob\n",
+			wantErr: errCSVEmptyKey,
+			wantPos: "row 3, column 1",
+		},
+		{
+			name:    "duplicate key",
+			parser:  &CSVParser{KeyColumn: "recipient"},
+			data:    "name,recipient\nAlice,alice\nBob,alice\n",
+			wantErr: errDuplicateRecipient,
+			wantPos: "row 3, column 5",
+		},
+		{
+			name:    "wrong number of fields",
+			parser:  &CSVParser{},
+			data:    "recipient,name\nalice,Alice,extra\n",
+			wantPos: "line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parser.Parse(strings.NewReader(tt.data))
+			if err == nil {
+				t.Fatal("CSVParser.Parse() expected error, got nil")
+			}
+			if !errors.Is(err, errCSVDecodeFailed) {
+				t.Errorf("CSVParser.Parse() error = %v, want wrapped %v", err, errCSVDecodeFailed)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CSVParser.Parse() error = %v, want wrapped %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantPos) {
+				t.Errorf("CSVParser.Parse() error = %q, want position %q", err, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestMessageParser_CSVFormat(t *testing.T) {
+	template := "Hello {{.name}}!"
+	data := "recipient,name\nalice,Alice\nbob,Bob\n"
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &CSVParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	if messages["alice"] != "Hello Alice!" {
+		t.Errorf("MessageParser.Parse() for recipient %q got %q, want %q", "alice", messages["alice"], "Hello Alice!")
+	}
+	if messages["bob"] != "Hello Bob!" {
+		t.Errorf("MessageParser.Parse() for recipient %q got %q, want %q", "bob", messages["bob"], "Hello Bob!")
+	}
+}
+
+func TestMessageParser_InvalidCSVData(t *testing.T) {
+	_, err := NewMessageParser(strings.NewReader("Hello {{.name}}!"), strings.NewReader("recipient,name\n\"alice,Alice\n"), &CSVParser{})
+	if !errors.Is(err, errDataParseFailed) {
+		t.Errorf("NewMessageParser() error = %v, want wrapped %v", err, errDataParseFailed)
+	}
+}
@@ -0,0 +1,124 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// DefaultsKey is the reserved data file entry whose values are shared by all recipients.
+// Recipient entries override default values; nested maps are merged key by key.
+const DefaultsKey = "_defaults"
+
+// applyDefaults removes the DefaultsKey entry from recipients and merges its values
+// into every remaining recipient
+func applyDefaults(recipients []Recipient) []Recipient {
+	index := slices.IndexFunc(recipients, func(r Recipient) bool { return r.Name == DefaultsKey })
+	if index < 0 {
+		return recipients
+	}
+	defaults := recipients[index].Data
+	recipients = slices.Delete(recipients, index, index+1)
+
+	for i := range recipients {
+		recipients[i].Data = mergeData(defaults, recipients[i].Data)
+	}
+	return recipients
+}
+
+// orderRecipients returns the entries of messages in the given key order.
+// Keys missing from order are appended in sorted order.
+func orderRecipients(messages map[string]TemplateData, order []string) []Recipient {
+	recipients := make([]Recipient, 0, len(messages))
+	seen := make(map[string]bool, len(messages))
+	for _, name := range order {
+		data, ok := messages[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		recipients = append(recipients, Recipient{Name: name, Data: data})
+	}
+
+	var rest []string
+	for name := range messages {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		recipients = append(recipients, Recipient{Name: name, Data: messages[name]})
+	}
+	return recipients
+}
+
+// mergeData returns a new map holding base overlaid with override
+func mergeData(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseMap, baseIsMap := merged[key].(map[string]any)
+		overrideMap, overrideIsMap := value.(map[string]any)
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeData(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// normalizeData converts decoded values of every recipient into template-friendly types
+func normalizeData(recipients []Recipient) []Recipient {
+	for _, recipient := range recipients {
+		for key, value := range recipient.Data {
+			recipient.Data[key] = normalizeValue(value)
+		}
+	}
+	return recipients
+}
+
+// normalizeValue recursively converts decoder-specific types so that templates can
+// access nested fields with {{.a.b}}, compare numbers and range over lists.
+// JSON numbers become int64 when integral and float64 otherwise, maps with
+// non-string keys become map[string]any, and null values become empty strings,
+// so fields left blank in the data print nothing.
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case TemplateData:
+		// yaml.v3 decodes nested mappings into the type of the enclosing map
+		return normalizeValue(map[string]any(v))
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeValue(item)
+		}
+		return v
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalizeValue(item)
+		}
+		return converted
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
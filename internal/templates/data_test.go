@@ -0,0 +1,95 @@
+package templates
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "string", value: "Alice", want: "Alice"},
+		{name: "integer", value: json.Number("42"), want: int64(42)},
+		{name: "float", value: json.Number("4.2"), want: 4.2},
+		{name: "null", value: nil, want: ""},
+		{
+			name:  "nested map",
+			value: map[string]any{"count": json.Number("1")},
+			want:  map[string]any{"count": int64(1)},
+		},
+		{
+			name:  "template data",
+			value: TemplateData{"inner": TemplateData{"a": "b"}},
+			want:  map[string]any{"inner": map[string]any{"a": "b"}},
+		},
+		{
+			name:  "non-string keys",
+			value: map[any]any{1: "one", true: map[any]any{"x": "y"}},
+			want:  map[string]any{"1": "one", "true": map[string]any{"x": "y"}},
+		},
+		{
+			name:  "list",
+			value: []any{json.Number("1"), map[any]any{"a": "b"}, nil},
+			want:  []any{int64(1), map[string]any{"a": "b"}, ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeValue(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	recipients := []Recipient{
+		{Name: "alice", Data: TemplateData{"name": "Alice"}},
+		{Name: DefaultsKey, Data: TemplateData{"date": "Monday", "links": map[string]any{"docs": "a", "wiki": "b"}}},
+		{Name: "bob", Data: TemplateData{"name": "Bob", "date": "Friday", "links": map[string]any{"wiki": "c"}}},
+	}
+
+	got := applyDefaults(recipients)
+
+	want := []Recipient{
+		{Name: "alice", Data: TemplateData{"name": "Alice", "date": "Monday", "links": map[string]any{"docs": "a", "wiki": "b"}}},
+		{Name: "bob", Data: TemplateData{"name": "Bob", "date": "Friday", "links": map[string]any{"docs": "a", "wiki": "c"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyDefaults() = %#v, want %#v", got, want)
+	}
+}
+
+func TestApplyDefaults_NoDefaults(t *testing.T) {
+	recipients := []Recipient{{Name: "alice", Data: TemplateData{"name": "Alice"}}}
+
+	got := applyDefaults(recipients)
+
+	if !reflect.DeepEqual(got, []Recipient{{Name: "alice", Data: TemplateData{"name": "Alice"}}}) {
+		t.Errorf("applyDefaults() = %#v", got)
+	}
+}
+
+func TestOrderRecipients(t *testing.T) {
+	messages := map[string]TemplateData{
+		"carol": {"n": "3"},
+		"alice": {"n": "1"},
+		"bob":   {"n": "2"},
+		"dave":  {"n": "4"},
+	}
+
+	got := orderRecipients(messages, []string{"carol", "alice", "carol", "unknown"})
+
+	names := make([]string, len(got))
+	for i, r := range got {
+		names[i] = r.Name
+	}
+	if want := []string{"carol", "alice", "bob", "dave"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("orderRecipients() order = %v, want %v", names, want)
+	}
+}
@@ -6,13 +6,35 @@ var (
 	// Template errors
 	errTemplateReadFailed   = errors.New("failed to read template content")
 	errTemplateParseFailed  = errors.New("failed to parse template syntax")
+	errPartialsReadFailed   = errors.New("failed to read partials directory")
+	errLayoutNotFound       = errors.New("layout template not found")
 	errTemplateRenderFailed = errors.New("failed to render template")
+	errUnbalancedHTML       = errors.New("unbalanced HTML tags in message")
+
+	// Front matter errors
+	errFrontMatterUnclosed   = errors.New("unclosed template front matter")
+	errFrontMatterInvalid    = errors.New("invalid template front matter")
+	errUnknownFrontMatterKey = errors.New("unknown front matter key")
+	errUnknownImportance     = errors.New("unknown message importance")
+	errUnknownTarget         = errors.New("unknown message target")
 
 	// Data parsing errors
 	errDataParseFailed  = errors.New("failed to parse message data")
 	errJSONDecodeFailed = errors.New("failed to decode JSON data")
 	errYAMLDecodeFailed = errors.New("failed to decode YAML data")
 	errTOMLDecodeFailed = errors.New("failed to decode TOML data")
+	errCSVDecodeFailed  = errors.New("failed to decode CSV data")
+
+	// Data layout errors
+	errDuplicateRecipient  = errors.New("duplicate recipient key")
+	errCSVKeyColumnMissing = errors.New("recipient key column not found in CSV header")
+	errCSVEmptyKey         = errors.New("empty recipient key")
+	errInvalidTarget       = errors.New("invalid message target")
+
+	// Option errors
+	errUnknownMissingKeyPolicy = errors.New("unknown missing key policy")
+	errUnknownContentFormat    = errors.New("unknown content format")
+	errIncompatibleOptions     = errors.New("incompatible parser options")
 
 	// Registry errors
 	errNoParserRegistered = errors.New("no parser registered for extension")
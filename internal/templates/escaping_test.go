@@ -0,0 +1,103 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageParser_HTMLEscapingContexts(t *testing.T) {
+	template := `<p>Hi {{.name}}, <a href="{{.link}}">{{.title}}</a> <a href="https://example.com/?q={{.query}}">search</a></p>`
+	data := `{
+		"alice": {"name": "Tom & <b>Jerry</b>", "link": "https://example.com/a?x=1&y=2", "title": "Docs", "query": "a b&c"},
+		"mallory": {"name": "M", "link": "javascript:alert(1)", "title": "<script>", "query": "\"><script>"}
+	}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, WithHTMLEscaping())
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"alice": `<p>Hi Tom &amp; &lt;b&gt;Jerry&lt;/b&gt;, <a href="https://example.com/a?x=1&amp;y=2">Docs</a> ` +
+			`<a href="https://example.com/?q=a%20b%26c">search</a></p>`,
+		"mallory": `<p>Hi M, <a>&lt;script&gt;</a> <a href="https://example.com/?q=%22%3e%3cscript%3e">search</a></p>`,
+	}
+	for recipient, wantMsg := range want {
+		if messages[recipient] != wantMsg {
+			t.Errorf("MessageParser.Parse() for recipient %q:\ngot:  %s\nwant: %s", recipient, messages[recipient], wantMsg)
+		}
+	}
+}
+
+func TestMessageParser_SafeEscapeHatch(t *testing.T) {
+	data := `{"alice": {"name": "<b>Alice</b>", "signature": "<i>Bob</i>", "link": "https://example.com"}}`
+
+	tests := []struct {
+		name     string
+		template string
+		opts     []Option
+		want     string
+	}{
+		{
+			name:     "html escaping",
+			template: `<p>{{.name}} {{safe .signature}} <a href="{{safeURL .link}}">x</a></p>`,
+			opts:     []Option{WithHTMLEscaping()},
+			want:     `<p>&lt;b&gt;Alice&lt;/b&gt; <i>Bob</i> <a href="https://example.com">x</a></p>`,
+		},
+		{
+			name:     "text escaping",
+			template: `<p>{{.name}} {{safe .signature}}</p>`,
+			want:     `<p>&lt;b&gt;Alice&lt;/b&gt; <i>Bob</i></p>`,
+		},
+		{
+			name:     "raw data",
+			template: `<p>{{.name}} {{safe .signature}}</p>`,
+			opts:     []Option{WithRawData()},
+			want:     `<p><b>Alice</b> <i>Bob</i></p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(tt.template), strings.NewReader(data), &JSONParser{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages[0].Body != tt.want {
+				t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_HTMLEscapingMissingKey(t *testing.T) {
+	mp, err := NewMessageParser(strings.NewReader("<p>{{.name}} {{.email}}</p>"), strings.NewReader(`{"alice": {"name": "A"}}`),
+		&JSONParser{}, WithHTMLEscaping())
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	_, err = mp.Parse()
+
+	var recipientErr *RecipientError
+	if !errors.As(err, &recipientErr) || !recipientErr.MissingKey() {
+		t.Errorf("MessageParser.Parse() error = %v, want missing key error", err)
+	}
+}
+
+func TestMessageParser_HTMLEscapingIncompatibleFormat(t *testing.T) {
+	_, err := NewMessageParser(strings.NewReader("{{.name}}"), strings.NewReader(`{}`), &JSONParser{},
+		WithHTMLEscaping(), WithContentFormat(ContentMarkdown))
+
+	if !errors.Is(err, errIncompatibleOptions) {
+		t.Errorf("NewMessageParser() error = %v, want %v", err, errIncompatibleOptions)
+	}
+}
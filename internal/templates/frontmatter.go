@@ -0,0 +1,184 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlFrontMatterDelimiter = "---"
+	tomlFrontMatterDelimiter = "+++"
+)
+
+// Message importance levels accepted in front matter
+const (
+	ImportanceNormal = "normal"
+	ImportanceHigh   = "high"
+	ImportanceUrgent = "urgent"
+)
+
+// Message target types accepted in front matter
+const (
+	TargetChannel = "channel"
+	TargetChat    = "chat"
+)
+
+// Metadata describes a send, read from the front matter block at the start of a template.
+// Front matter is YAML enclosed in --- lines or TOML enclosed in +++ lines, e.g.
+//
+//	---
+//	subject: Weekly report
+//	importance: high
+//	target: channel
+//	format: markdown
+//	data: recipients.yaml
+//	---
+//	Hello {{.name}}!
+//
+// Every field is optional; unset fields are empty.
+type Metadata struct {
+	// Subject is the message subject. It is a template rendered for each recipient,
+	// unless the template defines a "subject" template itself.
+	Subject string `yaml:"subject" toml:"subject"`
+	// Summary is the notification preview text, templated like Subject
+	Summary string `yaml:"summary" toml:"summary"`
+	// Importance is the message importance: normal, high or urgent
+	Importance string `yaml:"importance" toml:"importance"`
+	// Target is the type of conversation messages are sent to: channel or chat
+	Target string `yaml:"target" toml:"target"`
+	// Format is the content format name, as accepted by ParseContentFormat.
+	// It applies unless WithContentFormat is given.
+	Format string `yaml:"format" toml:"format"`
+	// Data is the default recipient data file. Relative paths are relative to the template file.
+	Data string `yaml:"data" toml:"data"`
+	// Layout is the layout template messages are rendered through.
+	// It applies unless WithLayout is given.
+	Layout string `yaml:"layout" toml:"layout"`
+}
+
+// ReadMetadata reads only the front matter of the template read from r.
+// It returns empty metadata if the template has no front matter.
+func ReadMetadata(r io.Reader) (Metadata, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		initializers.Logger.Error(errTemplateReadFailed.Error(), "error", err)
+		return Metadata{}, fmt.Errorf("%w: %w", errTemplateReadFailed, err)
+	}
+	meta, _, err := splitFrontMatter(content)
+	return meta, err
+}
+
+// splitFrontMatter separates the front matter from the template body.
+// Content without front matter is returned unchanged as the body.
+func splitFrontMatter(content []byte) (Metadata, frontMatterBody, error) {
+	body := frontMatterBody{content: string(content)}
+	delimiter := firstLine(body.content)
+	if delimiter != yamlFrontMatterDelimiter && delimiter != tomlFrontMatterDelimiter {
+		return Metadata{}, body, nil
+	}
+
+	lines := strings.SplitAfter(body.content, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t\r\n") == delimiter {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		initializers.Logger.Error(errFrontMatterUnclosed.Error(), "delimiter", delimiter)
+		return Metadata{}, body, fmt.Errorf("%w: missing closing %s", errFrontMatterUnclosed, delimiter)
+	}
+
+	source := strings.Join(lines[1:end], "")
+	var meta Metadata
+	var err error
+	if delimiter == yamlFrontMatterDelimiter {
+		err = decodeYAMLFrontMatter(source, &meta)
+	} else {
+		err = decodeTOMLFrontMatter(source, &meta)
+	}
+	if err == nil {
+		err = meta.validate()
+	}
+	if err != nil {
+		initializers.Logger.Error(errFrontMatterInvalid.Error(), "error", err)
+		return Metadata{}, body, fmt.Errorf("%w: %w", errFrontMatterInvalid, err)
+	}
+
+	body.content = strings.Join(lines[end+1:], "")
+	body.offset = end + 1
+	return meta, body, nil
+}
+
+// frontMatterBody is the template content following the front matter
+type frontMatterBody struct {
+	content string
+	// offset is the number of lines preceding content in the template file
+	offset int
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimRight(s, " \t\r")
+}
+
+func decodeYAMLFrontMatter(source string, meta *Metadata) error {
+	if strings.TrimSpace(source) == "" {
+		return nil
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(source))
+	decoder.KnownFields(true)
+	return decoder.Decode(meta)
+}
+
+func decodeTOMLFrontMatter(source string, meta *Metadata) error {
+	decoded, err := toml.NewDecoder(strings.NewReader(source)).Decode(meta)
+	if err != nil {
+		return err
+	}
+	if undecoded := decoded.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("%w: %s", errUnknownFrontMatterKey, undecoded[0])
+	}
+	return nil
+}
+
+// validate checks that fields with a fixed set of values hold one of them
+func (m Metadata) validate() error {
+	switch m.Importance {
+	case "", ImportanceNormal, ImportanceHigh, ImportanceUrgent:
+	default:
+		return fmt.Errorf("%w %q: want %s, %s or %s", errUnknownImportance, m.Importance, ImportanceNormal, ImportanceHigh, ImportanceUrgent)
+	}
+	switch m.Target {
+	case "", TargetChannel, TargetChat:
+	default:
+		return fmt.Errorf("%w %q: want %s or %s", errUnknownTarget, m.Target, TargetChannel, TargetChat)
+	}
+	if m.Format != "" {
+		if _, err := ParseContentFormat(m.Format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withMetadata returns cfg with the settings from front matter applied.
+// Settings given as options take precedence over front matter.
+func (cfg parserConfig) withMetadata(meta Metadata) (parserConfig, error) {
+	if meta.Format != "" && !cfg.formatSet {
+		// the format name was validated when the front matter was read
+		cfg.format, _ = ParseContentFormat(meta.Format)
+	}
+	if meta.Layout != "" && cfg.layout == "" {
+		cfg.layout = meta.Layout
+	}
+	return cfg, cfg.validate()
+}
@@ -0,0 +1,157 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     Metadata
+	}{
+		{
+			name:     "no front matter",
+			template: "Hello {{.name}}",
+			want:     Metadata{},
+		},
+		{
+			name:     "yaml",
+			template: "---\nsubject: Weekly report\nimportance: high\ntarget: channel\nformat: md\ndata: data.yaml\nlayout: base\n---\nHello",
+			want: Metadata{Subject: "Weekly report", Importance: ImportanceHigh, Target: TargetChannel,
+				Format: "md", Data: "data.yaml", Layout: "base"},
+		},
+		{
+			name:     "toml",
+			template: "+++\nsubject = \"Reminder\"\ntarget = \"chat\"\n+++\nHello",
+			want:     Metadata{Subject: "Reminder", Target: TargetChat},
+		},
+		{
+			name:     "crlf line endings",
+			template: "---\r\nsubject: Reminder\r\n---\r\nHello",
+			want:     Metadata{Subject: "Reminder"},
+		},
+		{
+			name:     "empty block",
+			template: "---\n---\nHello",
+			want:     Metadata{},
+		},
+		{
+			name:     "rule later in the template",
+			template: "Hello\n---\nsubject: x\n---\n",
+			want:     Metadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadMetadata(strings.NewReader(tt.template))
+			if err != nil {
+				t.Fatalf("ReadMetadata() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ReadMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMetadata_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  error
+	}{
+		{"unclosed", "---\nsubject: x\nHello", errFrontMatterUnclosed},
+		{"yaml syntax", "---\nsubject: [x\n---\n", errFrontMatterInvalid},
+		{"unknown yaml key", "---\nsubjcet: x\n---\n", errFrontMatterInvalid},
+		{"unknown toml key", "+++\nsubjcet = \"x\"\n+++\n", errUnknownFrontMatterKey},
+		{"importance", "---\nimportance: critical\n---\n", errUnknownImportance},
+		{"target", "---\ntarget: user\n---\n", errUnknownTarget},
+		{"format", "---\nformat: rtf\n---\n", errUnknownContentFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadMetadata(strings.NewReader(tt.template))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadMetadata() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMessageParser_FrontMatter(t *testing.T) {
+	template := "---\nsubject: Reminder\nformat: markdown\n---\n**Hi** {{.name}}"
+	data := `{"alice": {"name": "Alice"}}`
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"front matter format", nil, "<p><b>Hi</b> Alice</p>"},
+		{"option overrides front matter", []Option{WithContentFormat(ContentAuto)}, "**Hi** Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			if mp.Metadata().Subject != "Reminder" {
+				t.Errorf("MessageParser.Metadata() = %+v, want subject Reminder", mp.Metadata())
+			}
+
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages[0].Body != tt.want {
+				t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_FrontMatterIncompatibleFormat(t *testing.T) {
+	template := "---\nformat: plain\n---\nHi"
+
+	_, err := NewMessageParser(strings.NewReader(template), strings.NewReader(`{}`), &JSONParser{}, WithHTMLEscaping())
+
+	if !errors.Is(err, errIncompatibleOptions) {
+		t.Errorf("NewMessageParser() error = %v, want %v", err, errIncompatibleOptions)
+	}
+}
+
+func TestLint_FrontMatterSyntaxErrorLine(t *testing.T) {
+	template := "---\nsubject: x\n---\nHello\n{{.name}"
+
+	report, err := Lint(strings.NewReader(template), strings.NewReader(`{}`), &JSONParser{})
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+
+	want := SyntaxError{Line: 5, Column: 8, Message: "bad character U+007D '}'"}
+	if len(report.SyntaxErrors) != 1 || report.SyntaxErrors[0] != want {
+		t.Errorf("Lint() SyntaxErrors = %+v, want %+v", report.SyntaxErrors, want)
+	}
+}
+
+func TestMessageParser_FrontMatterRenderErrorLine(t *testing.T) {
+	template := "---\nsubject: Hi {{.name}}\n---\nHello\n{{.email}}"
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(`{"alice": {"name": "A"}}`), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	_, err = mp.Parse()
+
+	var recipientErr *RecipientError
+	if !errors.As(err, &recipientErr) || recipientErr.Line != 5 || recipientErr.Placeholder != ".email" {
+		t.Errorf("MessageParser.Parse() error = %v, want missing .email at line 5", err)
+	}
+}
@@ -0,0 +1,217 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// dateLayouts lists the layouts tried, in order, when a date is given as a string
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02.01.2006 15:04",
+	"02.01.2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+}
+
+// templateFuncs returns the functions available in every message template.
+// Functions taking the processed value accept it as the last argument,
+// so they can be used in pipelines such as {{.name | upper}}.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper":        stringFunc(strings.ToUpper),
+		"lower":        stringFunc(strings.ToLower),
+		"title":        stringFunc(titleCase),
+		"trim":         stringFunc(strings.TrimSpace),
+		"default":      defaultValue,
+		"parseDate":    parseDate,
+		"date":         formatDate,
+		"formatNumber": formatNumber,
+		"join":         join,
+		"truncate":     truncate,
+		"pluralize":    pluralize,
+	}
+}
+
+// stringFunc adapts f to accept any value, formatted with fmt.Sprint,
+// so that numbers and other non-string data can be passed to string functions
+func stringFunc(f func(string) string) func(any) string {
+	return func(value any) string {
+		return f(fmt.Sprint(value))
+	}
+}
+
+// titleCase upper-cases the first letter of every whitespace-separated word
+func titleCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	wordStart := true
+	for _, r := range s {
+		if wordStart {
+			b.WriteRune(unicode.ToUpper(r))
+		} else {
+			b.WriteRune(r)
+		}
+		wordStart = unicode.IsSpace(r)
+	}
+	return b.String()
+}
+
+// defaultValue returns value, or fallback if value is nil, empty or a zero value
+func defaultValue(fallback, value any) any {
+	if isEmpty(value) {
+		return fallback
+	}
+	return value
+}
+
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// parseDate parses value using layout. An empty layout tries the common layouts in dateLayouts.
+func parseDate(layout string, value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case int:
+		return time.Unix(int64(v), 0), nil
+	case int64:
+		return time.Unix(v, 0), nil
+	case string:
+		if layout != "" {
+			return time.Parse(layout, strings.TrimSpace(v))
+		}
+		for _, l := range dateLayouts {
+			if t, err := time.Parse(l, strings.TrimSpace(v)); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized date %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("cannot parse %T as date", value)
+	}
+}
+
+// formatDate formats a time.Time, unix timestamp or date string with layout
+func formatDate(layout string, value any) (string, error) {
+	t, err := parseDate("", value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// formatNumber formats value with the given number of decimals and comma thousands separators
+func formatNumber(decimals int, value any) (string, error) {
+	f, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+
+	formatted := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	intPart, fracPart, hasFrac := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	if f < 0 && strings.Trim(formatted, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if hasFrac {
+		b.WriteByte('.')
+		b.WriteString(fracPart)
+	}
+	return b.String(), nil
+}
+
+// join concatenates the elements of a list, converting each to its string form
+func join(sep string, list any) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("cannot join %T", list)
+	}
+
+	items := make([]string, v.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(items, sep), nil
+}
+
+// truncate shortens s to at most length characters, ending with an ellipsis when cut
+func truncate(length int, value any) string {
+	s := fmt.Sprint(value)
+	if length <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:length-1]) + "…"
+}
+
+// pluralize returns singular when count is exactly one and plural otherwise
+func pluralize(singular, plural string, count any) (string, error) {
+	n, err := toFloat(count)
+	if err != nil {
+		return "", err
+	}
+	if n == 1 {
+		return singular, nil
+	}
+	return plural, nil
+}
+
+func toFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(rv.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(rv.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return rv.Float(), nil
+		default:
+			return 0, fmt.Errorf("cannot use %T as number", value)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func renderWithFuncs(t *testing.T, tmpl string, data TemplateData) (string, error) {
+	t.Helper()
+	parsed, err := readTemplate(strings.NewReader(tmpl), parserConfig{})
+	if err != nil {
+		t.Fatalf("readTemplate() unexpected error: %v", err)
+	}
+	var b strings.Builder
+	err = parsed.Execute(&b, data)
+	return b.String(), err
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	data := TemplateData{
+		"name":    "  alice smith ",
+		"empty":   "",
+		"count":   int64(3),
+		"one":     int64(1),
+		"price":   1234567.891,
+		"agenda":  []any{"Intro", "Review", int64(3)},
+		"date":    "2025-12-01",
+		"unix":    int64(0),
+		"long":    "Zażółć gęślą jaźń",
+		"flag":    false,
+		"nothing": nil,
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "upper", tmpl: `{{.name | trim | upper}}`, want: "ALICE SMITH"},
+		{name: "upper number", tmpl: `{{upper .count}}`, want: "3"},
+		{name: "lower", tmpl: `{{lower "HeLLo"}}`, want: "hello"},
+		{name: "title", tmpl: `{{.name | trim | title}}`, want: "Alice Smith"},
+		{name: "trim", tmpl: `[{{trim .name}}]`, want: "[alice smith]"},
+		{name: "default empty", tmpl: `{{.empty | default "n/a"}}`, want: "n/a"},
+		{name: "default nil", tmpl: `{{.nothing | default "n/a"}}`, want: "n/a"},
+		{name: "default false", tmpl: `{{.flag | default "n/a"}}`, want: "n/a"},
+		{name: "default present", tmpl: `{{default "n/a" .count}}`, want: "3"},
+		{name: "date from string", tmpl: `{{date "Jan 2, 2006" .date}}`, want: "Dec 1, 2025"},
+		{name: "date from unix", tmpl: `{{date "2006" .unix}}`, want: time.Unix(0, 0).Format("2006")},
+		{name: "parseDate with layout", tmpl: `{{(parseDate "02/01/2006" "24/12/2025").Format "2006-01-02"}}`, want: "2025-12-24"},
+		{name: "formatNumber", tmpl: `{{formatNumber 2 .price}}`, want: "1,234,567.89"},
+		{name: "formatNumber integer", tmpl: `{{formatNumber 0 1000}}`, want: "1,000"},
+		{name: "formatNumber negative", tmpl: `{{formatNumber 1 -1234.56}}`, want: "-1,234.6"},
+		{name: "formatNumber string", tmpl: `{{formatNumber 0 "999"}}`, want: "999"},
+		{name: "join", tmpl: `{{join ", " .agenda}}`, want: "Intro, Review, 3"},
+		{name: "join nil", tmpl: `[{{join ", " .nothing}}]`, want: "[]"},
+		{name: "truncate", tmpl: `{{truncate 6 .long}}`, want: "Zażół…"},
+		{name: "truncate short", tmpl: `{{truncate 100 .long}}`, want: "Zażółć gęślą jaźń"},
+		{name: "pluralize many", tmpl: `{{.count}} {{pluralize "item" "items" .count}}`, want: "3 items"},
+		{name: "pluralize one", tmpl: `{{.one}} {{.one | pluralize "item" "items"}}`, want: "1 item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderWithFuncs(t, tt.tmpl, data)
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFuncs_Errors(t *testing.T) {
+	data := TemplateData{
+		"date":   "not a date",
+		"word":   "many",
+		"object": map[string]any{"a": "b"},
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+	}{
+		{name: "date unrecognized", tmpl: `{{date "2006" .date}}`},
+		{name: "parseDate wrong layout", tmpl: `{{parseDate "2006-01-02" "01/02/2006"}}`},
+		{name: "formatNumber not a number", tmpl: `{{formatNumber 2 .word}}`},
+		{name: "join not a list", tmpl: `{{join ", " .object}}`},
+		{name: "pluralize not a number", tmpl: `{{pluralize "a" "b" .word}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := renderWithFuncs(t, tt.tmpl, data); err == nil {
+				t.Error("Execute() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestMessageParser_DefaultMissingKey(t *testing.T) {
+	template := `{{default "guest" .nickname}} {{.title | default "-"}} {{default "?" .room.floor}}`
+	data := `{"alice": {"nickname": "Al"}, "bob": {}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	want := map[string]string{"alice": "Al - ?", "bob": "guest - ?"}
+	for recipient, wantMsg := range want {
+		if got := messages[recipient]; got != wantMsg {
+			t.Errorf("MessageParser.Parse() for recipient %q got %q, want %q", recipient, got, wantMsg)
+		}
+	}
+}
+
+func TestMessageParser_DefaultDoesNotHideRequiredKey(t *testing.T) {
+	template := `{{default "guest" .nickname}} {{.nickname}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(`{"bob": {}}`), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	if _, err := mp.Parse(); err == nil {
+		t.Error("MessageParser.Parse() expected missing key error, got nil")
+	}
+}
@@ -12,12 +12,53 @@ import (
 type JSONParser struct{}
 
 // Parse reads JSON-formatted message data
-func (p *JSONParser) Parse(r io.Reader) (map[string]TemplateData, error) {
-	var messages map[string]TemplateData
+func (p *JSONParser) Parse(r io.Reader) ([]Recipient, error) {
 	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&messages); err != nil {
+	decoder.UseNumber()
+
+	recipients, err := decodeJSONObject(decoder)
+	if err != nil {
 		initializers.Logger.Error(errJSONDecodeFailed.Error(), "error", err)
 		return nil, fmt.Errorf("%w: %w", errJSONDecodeFailed, err)
 	}
-	return messages, nil
+	return applyDefaults(normalizeData(recipients)), nil
+}
+
+// decodeJSONObject reads the top-level object token by token to keep its key order
+func decodeJSONObject(decoder *json.Decoder) ([]Recipient, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object of recipients, got %v", token)
+	}
+
+	var recipients []Recipient
+	seen := make(map[string]bool)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := token.(string)
+		if seen[name] {
+			return nil, fmt.Errorf("%w %q", errDuplicateRecipient, name)
+		}
+		seen[name] = true
+
+		var data TemplateData
+		if err := decoder.Decode(&data); err != nil {
+			return nil, fmt.Errorf("recipient %q: %w", name, err)
+		}
+		recipients = append(recipients, Recipient{Name: name, Data: data})
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return recipients, nil
 }
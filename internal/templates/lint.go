@@ -0,0 +1,209 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+var (
+	// parseErrorRegex extracts the line and description from text/template parse errors, e.g.
+	// template: message:3: unexpected "}" in operand
+	parseErrorRegex = regexp.MustCompile(`template: [^:]*:(\d+): (.*)$`)
+	// quotedTokenRegex finds the offending token quoted in a parse error description
+	quotedTokenRegex = regexp.MustCompile(`"([^"]+)"|'([^']+)'`)
+)
+
+// SyntaxError locates a template syntax error
+type SyntaxError struct {
+	// File is the partial containing the error, or empty for the message template
+	File string
+	// Line is the 1-based line of the error
+	Line int
+	// Column is the 1-based column of the offending token, or 0 if it cannot be determined
+	Column int
+	// Message describes the error
+	Message string
+}
+
+// String formats the error with its position, e.g. "line 3, column 7: unclosed action"
+func (e SyntaxError) String() string {
+	position := fmt.Sprintf("line %d", e.Line)
+	if e.Column > 0 {
+		position += fmt.Sprintf(", column %d", e.Column)
+	}
+	if e.File != "" {
+		position = e.File + ": " + position
+	}
+	return position + ": " + e.Message
+}
+
+// MissingPlaceholders lists placeholders a recipient's data does not provide
+type MissingPlaceholders struct {
+	Recipient    string
+	Placeholders []string
+}
+
+// LintReport is the result of statically checking a template against message data
+type LintReport struct {
+	// Recipients is the number of recipients in the data
+	Recipients int
+	// Placeholders lists the placeholders referenced by the template, e.g. "room.name"
+	Placeholders []string
+	// Unused lists top-level data keys that no placeholder references
+	Unused []string
+	// Missing lists, per recipient in data order, the referenced placeholders absent from its data
+	Missing []MissingPlaceholders
+	// SyntaxErrors lists template syntax errors; other fields are empty when it is set
+	SyntaxErrors []SyntaxError
+}
+
+// HasProblems reports whether the template would fail to parse or to render for some recipient.
+// Unused data keys are not considered problems.
+func (r *LintReport) HasProblems() bool {
+	return len(r.SyntaxErrors) > 0 || len(r.Missing) > 0
+}
+
+// Lint checks the template read from templateReader against the data parsed from
+// dataReader without rendering any message. Options that affect template parsing,
+// such as WithHTMLEscaping, are applied as in NewMessageParser. Under MissingKeyDefault
+// missing placeholders render the default value and are not reported. Template syntax errors
+// are returned in the report; an error is returned if reading the inputs fails.
+func Lint(templateReader, dataReader io.Reader, dataParser Parser, opts ...Option) (*LintReport, error) {
+	config, err := newParserConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := readTemplate(templateReader, config)
+	var syntaxErr *templateSyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &LintReport{SyntaxErrors: []SyntaxError{newSyntaxError(syntaxErr)}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := dataParser.Parse(dataReader)
+	if err != nil {
+		initializers.Logger.Error(errDataParseFailed.Error(), "error", err)
+		return nil, fmt.Errorf("%w: %w", errDataParseFailed, err)
+	}
+	if err := resolveTargets(recipients, tmpl.metadata.Target); err != nil {
+		initializers.Logger.Error(errInvalidTarget.Error(), "error", err)
+		return nil, err
+	}
+
+	paths := collectPlaceholders(tmpl)
+	report := &LintReport{
+		Recipients:   len(recipients),
+		Placeholders: make([]string, len(paths)),
+		Unused:       unusedKeys(recipients, paths),
+	}
+	for i, path := range paths {
+		report.Placeholders[i] = strings.Join(path, ".")
+	}
+
+	if config.missingKey != MissingKeyDefault {
+		report.Missing = missingPlaceholders(recipients, paths, collectDefaulted(tmpl))
+	}
+
+	initializers.Logger.Info("Template linted", "placeholders", len(report.Placeholders),
+		"unused", len(report.Unused), "recipients_missing_keys", len(report.Missing))
+	return report, nil
+}
+
+// missingPlaceholders lists, per recipient, the paths absent from its data
+// that are not given a value with default
+func missingPlaceholders(recipients []Recipient, paths, defaultedPaths [][]string) []MissingPlaceholders {
+	defaulted := make(map[string]bool)
+	for _, path := range defaultedPaths {
+		defaulted[strings.Join(path, ".")] = true
+	}
+	var result []MissingPlaceholders
+	for _, recipient := range recipients {
+		var missing []string
+		for _, path := range paths {
+			if !defaulted[strings.Join(path, ".")] && !hasPath(recipient.Data, path) {
+				missing = append(missing, strings.Join(path, "."))
+			}
+		}
+		if len(missing) > 0 {
+			result = append(result, MissingPlaceholders{Recipient: recipient.Name, Placeholders: missing})
+		}
+	}
+	return result
+}
+
+// newSyntaxError extracts the position of a text/template parse error.
+// text/template only reports lines, so the column is that of the quoted offending token, if any.
+func newSyntaxError(err *templateSyntaxError) SyntaxError {
+	m := parseErrorRegex.FindStringSubmatch(err.err.Error())
+	if m == nil {
+		return SyntaxError{File: err.file, Message: err.err.Error()}
+	}
+
+	// positions in the body are reported as positions in the template file, after the front matter
+	lineNumber, _ := strconv.Atoi(m[1])
+	syntaxErr := SyntaxError{File: err.file, Line: lineNumber + err.offset, Message: m[2]}
+
+	lines := strings.Split(err.content, "\n")
+	if lineNumber < 1 || lineNumber > len(lines) {
+		return syntaxErr
+	}
+	line := lines[lineNumber-1]
+	if token := quotedTokenRegex.FindStringSubmatch(m[2]); token != nil {
+		if actionStart := strings.Index(line, "{{"); actionStart >= 0 {
+			if i := strings.Index(line[actionStart:], token[1]+token[2]); i >= 0 {
+				syntaxErr.Column = actionStart + i + 1
+			}
+		}
+	} else if i := strings.LastIndex(line, "{{"); i >= 0 {
+		syntaxErr.Column = i + 1
+	}
+	return syntaxErr
+}
+
+// hasPath reports whether data holds a value at path. Paths through values
+// that are not maps, such as lists, cannot be checked statically and count as present.
+func hasPath(data map[string]any, path []string) bool {
+	value, ok := data[path[0]]
+	if !ok {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	nested, isMap := value.(map[string]any)
+	if !isMap {
+		return true
+	}
+	return hasPath(nested, path[1:])
+}
+
+// unusedKeys returns the sorted top-level data keys not referenced by any placeholder path
+func unusedKeys(recipients []Recipient, paths [][]string) []string {
+	used := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		used[path[0]] = true
+	}
+
+	seen := make(map[string]bool)
+	var unused []string
+	for _, recipient := range recipients {
+		for key := range recipient.Data {
+			if !used[key] && !seen[key] {
+				seen[key] = true
+				unused = append(unused, key)
+			}
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
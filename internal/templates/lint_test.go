@@ -0,0 +1,106 @@
+package templates
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint_ReportsPlaceholdersUnusedAndMissing(t *testing.T) {
+	template := "Hello {{.name}}! {{with .room}}Room {{.name}}{{end}} {{range .agenda}}{{.}}{{end}}"
+	data := `{
+		"_defaults": {"agenda": ["Intro"]},
+		"alice": {"name": "Alice", "room": {"name": "Blue"}, "phone": "123"},
+		"bob": {"room": {}},
+		"carol": {"name": "Carol", "room": {"name": "Red"}, "email": "c@example.com"}
+	}`
+
+	report, err := Lint(strings.NewReader(template), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+
+	if report.Recipients != 3 {
+		t.Errorf("Lint() Recipients = %d, want 3", report.Recipients)
+	}
+	if want := []string{"agenda", "name", "room", "room.name"}; !reflect.DeepEqual(report.Placeholders, want) {
+		t.Errorf("Lint() Placeholders = %v, want %v", report.Placeholders, want)
+	}
+	if want := []string{"email", "phone"}; !reflect.DeepEqual(report.Unused, want) {
+		t.Errorf("Lint() Unused = %v, want %v", report.Unused, want)
+	}
+	want := []MissingPlaceholders{{Recipient: "bob", Placeholders: []string{"name", "room.name"}}}
+	if !reflect.DeepEqual(report.Missing, want) {
+		t.Errorf("Lint() Missing = %v, want %v", report.Missing, want)
+	}
+	if !report.HasProblems() {
+		t.Error("LintReport.HasProblems() = false, want true")
+	}
+}
+
+func TestLint_Clean(t *testing.T) {
+	report, err := Lint(strings.NewReader("Hi {{.name}}"), strings.NewReader("name\nAlice\n"), &CSVParser{})
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+	if report.HasProblems() || len(report.Unused) != 0 {
+		t.Errorf("Lint() report = %+v, want no findings", report)
+	}
+}
+
+func TestLint_DefaultedPlaceholderNotMissing(t *testing.T) {
+	report, err := Lint(strings.NewReader(`Hi {{.name}} {{default "guest" .nickname}}`), strings.NewReader("name\nAlice\n"), &CSVParser{})
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+	if report.HasProblems() {
+		t.Errorf("Lint() report = %+v, want no missing placeholders", report)
+	}
+}
+
+func TestLint_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     SyntaxError
+	}{
+		{
+			name:     "bad character",
+			template: "Hello\nDear {{.name}!",
+			want:     SyntaxError{Line: 2, Column: 13, Message: "bad character U+007D '}'"},
+		},
+		{
+			name:     "unknown function",
+			template: "{{.name | shout}}",
+			want:     SyntaxError{Line: 1, Column: 11, Message: `function "shout" not defined`},
+		},
+		{
+			name:     "unclosed action",
+			template: "a\nb {{.name",
+			want:     SyntaxError{Line: 2, Column: 3, Message: "unclosed action"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Lint(strings.NewReader(tt.template), strings.NewReader(`{}`), &JSONParser{})
+			if err != nil {
+				t.Fatalf("Lint() unexpected error: %v", err)
+			}
+			if len(report.SyntaxErrors) != 1 || report.SyntaxErrors[0] != tt.want {
+				t.Errorf("Lint() SyntaxErrors = %+v, want %+v", report.SyntaxErrors, tt.want)
+			}
+			if !report.HasProblems() {
+				t.Error("LintReport.HasProblems() = false, want true")
+			}
+		})
+	}
+}
+
+func TestLint_InvalidData(t *testing.T) {
+	_, err := Lint(strings.NewReader("{{.name}}"), strings.NewReader(`{"alice": }`), &JSONParser{})
+	if !errors.Is(err, errDataParseFailed) {
+		t.Errorf("Lint() error = %v, want wrapped %v", err, errDataParseFailed)
+	}
+}
@@ -0,0 +1,306 @@
+package templates
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdHeadingRegex    = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	mdRuleRegex       = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdFenceRegex      = regexp.MustCompile("^ {0,3}(```|~~~)")
+	mdListItemRegex   = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+	mdQuoteRegex      = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	mdEscapeRegex     = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!~>|])")
+	mdLinkRegex       = regexp.MustCompile(`\[([^\]]+)\]\(([^()\s]+)\)`)
+	mdAutoLinkRegex   = regexp.MustCompile(`&lt;((?:https?://|mailto:)[^\s&]+)&gt;`)
+	mdLinkSlotRegex   = regexp.MustCompile("\x00[0-9]+\x00")
+	mdBoldRegex       = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	mdItalicRegex     = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*|(?:^|\b)_(\S(?:[^_]*?\S)?)_(?:\b|$)`)
+	mdStrikeRegex     = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	mdSafeSchemeRegex = regexp.MustCompile(`^(?i:https?://|mailto:)`)
+)
+
+// renderMarkdown converts Markdown into the HTML subset rendered by Teams messages:
+// headings, paragraphs, bold, italic, strikethrough, inline code, fenced code blocks,
+// bulleted and numbered lists, block quotes, horizontal rules and links.
+// Single line breaks inside a paragraph are kept as <br>, as in chat messages.
+// Raw HTML in the source is escaped and shown as text.
+func renderMarkdown(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	return renderMarkdownBlocks(strings.Split(src, "\n"))
+}
+
+func renderMarkdownBlocks(lines []string) string {
+	var b strings.Builder
+	var paragraph []string
+
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		inline := make([]string, len(paragraph))
+		for i, line := range paragraph {
+			inline[i] = renderMarkdownInline(strings.TrimSpace(line))
+		}
+		b.WriteString("<p>" + strings.Join(inline, "<br>") + "</p>")
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+			i++
+		case mdFenceRegex.MatchString(line):
+			flushParagraph()
+			var code string
+			code, i = readCodeFence(lines, i)
+			b.WriteString("<pre><code>" + html.EscapeString(code) + "</code></pre>")
+		case mdHeadingRegex.MatchString(line):
+			flushParagraph()
+			m := mdHeadingRegex.FindStringSubmatch(line)
+			tag := headingTag(len(m[1]))
+			b.WriteString("<" + tag + ">" + renderMarkdownInline(m[2]) + "</" + tag + ">")
+			i++
+		case mdRuleRegex.MatchString(line):
+			flushParagraph()
+			b.WriteString("<hr>")
+			i++
+		case mdQuoteRegex.MatchString(line):
+			flushParagraph()
+			var quoted []string
+			for ; i < len(lines) && mdQuoteRegex.MatchString(lines[i]); i++ {
+				quoted = append(quoted, mdQuoteRegex.FindStringSubmatch(lines[i])[1])
+			}
+			b.WriteString("<blockquote>" + renderMarkdownBlocks(quoted) + "</blockquote>")
+		case mdListItemRegex.MatchString(line) && len(paragraph) == 0:
+			var list string
+			list, i = readList(lines, i)
+			b.WriteString(list)
+		default:
+			paragraph = append(paragraph, line)
+			i++
+		}
+	}
+	flushParagraph()
+
+	return b.String()
+}
+
+// headingTag maps Markdown heading levels onto the h1-h3 headings Teams renders
+func headingTag(level int) string {
+	switch level {
+	case 1:
+		return "h1"
+	case 2:
+		return "h2"
+	default:
+		return "h3"
+	}
+}
+
+// readCodeFence returns the content of the fenced code block starting at lines[start]
+// and the index of the line following it. An unclosed fence runs to the end of input.
+func readCodeFence(lines []string, start int) (string, int) {
+	fence := mdFenceRegex.FindStringSubmatch(lines[start])[1]
+	var code []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			return strings.Join(code, "\n"), i + 1
+		}
+		code = append(code, lines[i])
+	}
+	return strings.Join(code, "\n"), i
+}
+
+// listLine is the kind of a line met while reading a list
+type listLine int
+
+// Kinds of lines met while reading a list
+const (
+	listLineEnd listLine = iota
+	listLineItem
+	listLineNested
+	listLineContinuation
+)
+
+// readList renders the list starting at lines[start], including lists nested by
+// indentation, and returns the index of the first line after it
+func readList(lines []string, start int) (string, int) {
+	first := mdListItemRegex.FindStringSubmatch(lines[start])
+	indent := indentWidth(first[1])
+	ordered := isOrderedMarker(first[2])
+	w := newListWriter(ordered)
+
+	i := start
+	for i < len(lines) {
+		if strings.TrimSpace(lines[i]) == "" {
+			// a blank line ends the list unless another item of it follows
+			if i+1 < len(lines) && isListItemAt(lines[i+1], indent, ordered) {
+				i++
+				continue
+			}
+			break
+		}
+
+		kind, text := classifyListLine(lines[i], indent, ordered, w.inItem)
+		switch kind {
+		case listLineNested:
+			nested, next := readList(lines, i)
+			w.b.WriteString(nested)
+			i = next
+			continue
+		case listLineItem:
+			w.item(text)
+		case listLineContinuation:
+			w.b.WriteString("<br>" + renderMarkdownInline(text))
+		default:
+			return w.close(), i
+		}
+		i++
+	}
+	return w.close(), i
+}
+
+// classifyListLine tells how a non-blank line continues a list at indent,
+// returning the text of an item or continuation line
+func classifyListLine(line string, indent int, ordered, inItem bool) (listLine, string) {
+	m := mdListItemRegex.FindStringSubmatch(line)
+	switch {
+	case m != nil && indentWidth(m[1]) > indent:
+		return listLineNested, ""
+	case m != nil && indentWidth(m[1]) == indent && isOrderedMarker(m[2]) == ordered:
+		return listLineItem, m[3]
+	case m == nil && indentWidth(line) > indent && inItem:
+		return listLineContinuation, strings.TrimSpace(line)
+	default:
+		return listLineEnd, ""
+	}
+}
+
+// listWriter writes the HTML of a single list
+type listWriter struct {
+	b      strings.Builder
+	tag    string
+	inItem bool
+}
+
+func newListWriter(ordered bool) *listWriter {
+	w := &listWriter{tag: "ul"}
+	if ordered {
+		w.tag = "ol"
+	}
+	w.b.WriteString("<" + w.tag + ">")
+	return w
+}
+
+// item closes the current item, if any, and starts a new one with text
+func (w *listWriter) item(text string) {
+	if w.inItem {
+		w.b.WriteString("</li>")
+	}
+	w.b.WriteString("<li>" + renderMarkdownInline(text))
+	w.inItem = true
+}
+
+// close ends the open item and the list, returning its HTML
+func (w *listWriter) close() string {
+	if w.inItem {
+		w.b.WriteString("</li>")
+	}
+	w.b.WriteString("</" + w.tag + ">")
+	return w.b.String()
+}
+
+func isListItemAt(line string, indent int, ordered bool) bool {
+	m := mdListItemRegex.FindStringSubmatch(line)
+	return m != nil && indentWidth(m[1]) >= indent && (indentWidth(m[1]) > indent || isOrderedMarker(m[2]) == ordered)
+}
+
+func isOrderedMarker(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// indentWidth returns the width of the leading whitespace of s, counting tabs as four spaces
+func indentWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// renderMarkdownInline converts inline Markdown in a single line of text.
+// Code spans are rendered literally; the rest is HTML-escaped before formatting.
+func renderMarkdownInline(text string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(text, '`')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start+1:], '`')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+		b.WriteString(formatMarkdownText(text[:start]))
+		b.WriteString("<code>" + html.EscapeString(text[start+1:end]) + "</code>")
+		text = text[end+1:]
+	}
+	b.WriteString(formatMarkdownText(text))
+	return b.String()
+}
+
+// formatMarkdownText escapes text and applies emphasis and link formatting.
+// Backslash-escaped characters become numeric entities so no rule matches them.
+// Links are set aside while emphasis is applied, so that markers in their
+// targets are left alone, and are put back last.
+func formatMarkdownText(text string) string {
+	text = html.EscapeString(strings.ReplaceAll(text, "\x00", "\uFFFD"))
+	text = mdEscapeRegex.ReplaceAllStringFunc(text, func(escaped string) string {
+		return "&#" + strconv.Itoa(int(escaped[1])) + ";"
+	})
+
+	var links []string
+	setAside := func(link string) string {
+		links = append(links, link)
+		return "\x00" + strconv.Itoa(len(links)-1) + "\x00"
+	}
+	text = mdLinkRegex.ReplaceAllStringFunc(text, func(link string) string {
+		m := mdLinkRegex.FindStringSubmatch(link)
+		if !mdSafeSchemeRegex.MatchString(html.UnescapeString(m[2])) {
+			return m[1]
+		}
+		return setAside(`<a href="` + m[2] + `">` + formatEmphasis(m[1]) + "</a>")
+	})
+	text = mdAutoLinkRegex.ReplaceAllStringFunc(text, func(link string) string {
+		url := mdAutoLinkRegex.FindStringSubmatch(link)[1]
+		return setAside(`<a href="` + url + `">` + url + "</a>")
+	})
+
+	text = formatEmphasis(text)
+	return mdLinkSlotRegex.ReplaceAllStringFunc(text, func(slot string) string {
+		i, _ := strconv.Atoi(slot[1 : len(slot)-1])
+		return links[i]
+	})
+}
+
+// formatEmphasis applies bold, italic and strikethrough formatting to escaped text
+func formatEmphasis(text string) string {
+	text = mdBoldRegex.ReplaceAllString(text, "<b>$1$2</b>")
+	text = mdItalicRegex.ReplaceAllString(text, "<i>$1$2</i>")
+	return mdStrikeRegex.ReplaceAllString(text, "<s>$1</s>")
+}
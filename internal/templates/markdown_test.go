@@ -0,0 +1,149 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "paragraphs and line breaks",
+			src:  "Hello Alice!\nSee you soon.\r\n\r\nBye",
+			want: "<p>Hello Alice!<br>See you soon.</p><p>Bye</p>",
+		},
+		{
+			name: "headings",
+			src:  "# Title\n## Section ##\n#### Deep",
+			want: "<h1>Title</h1><h2>Section</h2><h3>Deep</h3>",
+		},
+		{
+			name: "emphasis",
+			src:  "**bold** __also bold__ *italic* _too_ ~~gone~~ snake_case_name",
+			want: "<p><b>bold</b> <b>also bold</b> <i>italic</i> <i>too</i> <s>gone</s> snake_case_name</p>",
+		},
+		{
+			name: "inline code is literal",
+			src:  "Run `go test **./...**` now",
+			want: "<p>Run <code>go test **./...**</code> now</p>",
+		},
+		{
+			name: "links",
+			src:  "[Docs](https://example.com/docs?a=1&b=2) <https://example.com> [bad](javascript:alert(1))",
+			want: `<p><a href="https://example.com/docs?a=1&amp;b=2">Docs</a> <a href="https://example.com">https://example.com</a> [bad](javascript:alert(1))</p>`,
+		},
+		{
+			name: "link targets keep emphasis markers",
+			src:  "**See** [the_wiki_page](https://example.com/a_b_c/*x*) and <https://example.com/__init__>",
+			want: `<p><b>See</b> <a href="https://example.com/a_b_c/*x*">the_wiki_page</a> and <a href="https://example.com/__init__">https://example.com/__init__</a></p>`,
+		},
+		{
+			name: "emphasis around a link",
+			src:  "**read [this](https://example.com/*) first**",
+			want: `<p><b>read <a href="https://example.com/*">this</a> first</b></p>`,
+		},
+		{
+			name: "unsafe link scheme keeps text only",
+			src:  "[click](javascript:void)",
+			want: "<p>click</p>",
+		},
+		{
+			name: "escaped characters",
+			src:  `\*not italic\* 2 \* 3`,
+			want: "<p>&#42;not italic&#42; 2 &#42; 3</p>",
+		},
+		{
+			name: "raw html is escaped",
+			src:  "<script>alert('x')</script> & more",
+			want: "<p>&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt; &amp; more</p>",
+		},
+		{
+			name: "fenced code block",
+			src:  "Before\n```go\nif a < b {\n  **x**\n}\n```\nAfter",
+			want: "<p>Before</p><pre><code>if a &lt; b {\n  **x**\n}</code></pre><p>After</p>",
+		},
+		{
+			name: "bulleted list with continuation",
+			src:  "Agenda:\n\n- Intro\n- **Review**\n  of Q4\n* Wrap up\n\nThanks",
+			want: "<p>Agenda:</p><ul><li>Intro</li><li><b>Review</b><br>of Q4</li><li>Wrap up</li></ul><p>Thanks</p>",
+		},
+		{
+			name: "numbered list with nested list",
+			src:  "1. First\n   - a\n   - b\n2. Second",
+			want: "<ol><li>First<ul><li>a</li><li>b</li></ul></li><li>Second</li></ol>",
+		},
+		{
+			name: "list items separated by blank lines",
+			src:  "- one\n\n- two",
+			want: "<ul><li>one</li><li>two</li></ul>",
+		},
+		{
+			name: "block quote",
+			src:  "> quoted **text**\n> more\n\nafter",
+			want: "<blockquote><p>quoted <b>text</b><br>more</p></blockquote><p>after</p>",
+		},
+		{
+			name: "horizontal rule",
+			src:  "above\n\n---\n\nbelow",
+			want: "<p>above</p><hr><p>below</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.src); got != tt.want {
+				t.Errorf("renderMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_ContentFormats(t *testing.T) {
+	template := "Hi **{{.name}}**\n<b>x</b>"
+	data := `{"alice": {"name": "Alice"}}`
+
+	tests := []struct {
+		format ContentFormat
+		want   string
+	}{
+		{format: ContentAuto, want: "Hi **Alice**\n<b>x</b>"},
+		{format: ContentHTML, want: "Hi **Alice**\n<b>x</b>"},
+		{format: ContentPlain, want: "Hi **Alice**<br>&lt;b&gt;x&lt;/b&gt;"},
+		{format: ContentMarkdown, want: "<p>Hi <b>Alice</b><br>&lt;b&gt;x&lt;/b&gt;</p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, WithContentFormat(tt.format))
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages[0].Body != tt.want {
+				t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseContentFormat(t *testing.T) {
+	for _, format := range []ContentFormat{ContentAuto, ContentPlain, ContentHTML, ContentMarkdown} {
+		got, err := ParseContentFormat(format.String())
+		if err != nil || got != format {
+			t.Errorf("ParseContentFormat(%q) = %v, %v", format.String(), got, err)
+		}
+	}
+	if got, err := ParseContentFormat("md"); err != nil || got != ContentMarkdown {
+		t.Errorf("ParseContentFormat(%q) = %v, %v", "md", got, err)
+	}
+	if _, err := ParseContentFormat("rtf"); err == nil {
+		t.Error("ParseContentFormat() expected error for unknown format, got nil")
+	}
+}
@@ -0,0 +1,200 @@
+package templates
+
+import "fmt"
+
+// MissingKeyPolicy controls how TemplateParser.Parse handles placeholders
+// that are absent from a recipient's data
+type MissingKeyPolicy int
+
+// Available missing key policies
+const (
+	// MissingKeyFail aborts rendering at the first recipient with a missing placeholder
+	MissingKeyFail MissingKeyPolicy = iota
+	// MissingKeySkip leaves out recipients with missing placeholders and reports them
+	MissingKeySkip
+	// MissingKeyDefault renders missing placeholders with a default value
+	MissingKeyDefault
+)
+
+// String returns the policy name as accepted by ParseMissingKeyPolicy
+func (p MissingKeyPolicy) String() string {
+	switch p {
+	case MissingKeySkip:
+		return "skip"
+	case MissingKeyDefault:
+		return "default"
+	default:
+		return "fail"
+	}
+}
+
+// ParseMissingKeyPolicy returns the policy with the given name: fail, skip or default
+func ParseMissingKeyPolicy(name string) (MissingKeyPolicy, error) {
+	for _, p := range []MissingKeyPolicy{MissingKeyFail, MissingKeySkip, MissingKeyDefault} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return MissingKeyFail, fmt.Errorf("%w: %q", errUnknownMissingKeyPolicy, name)
+}
+
+// ContentFormat selects how rendered template output is converted into Teams message HTML
+type ContentFormat int
+
+// Available content formats
+const (
+	// ContentAuto passes output containing basic HTML tags through unchanged
+	// and converts line breaks of any other output into <br>
+	ContentAuto ContentFormat = iota
+	// ContentPlain treats output as plain text: HTML special characters are escaped
+	// and line breaks are converted into <br>
+	ContentPlain
+	// ContentHTML passes output through unchanged
+	ContentHTML
+	// ContentMarkdown converts output from Markdown into HTML
+	ContentMarkdown
+)
+
+// String returns the format name as accepted by ParseContentFormat
+func (f ContentFormat) String() string {
+	switch f {
+	case ContentPlain:
+		return "plain"
+	case ContentHTML:
+		return "html"
+	case ContentMarkdown:
+		return "markdown"
+	default:
+		return "auto"
+	}
+}
+
+// ParseContentFormat returns the format with the given name: auto, plain, html, markdown or md
+func ParseContentFormat(name string) (ContentFormat, error) {
+	if name == "md" {
+		return ContentMarkdown, nil
+	}
+	for _, f := range []ContentFormat{ContentAuto, ContentPlain, ContentHTML, ContentMarkdown} {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return ContentAuto, fmt.Errorf("%w: %q", errUnknownContentFormat, name)
+}
+
+// Option configures a TemplateParser
+type Option func(*parserConfig)
+
+type parserConfig struct {
+	missingKey        MissingKeyPolicy
+	missingKeyDefault string
+	collectErrors     bool
+	format            ContentFormat
+	formatSet         bool
+	rawData           bool
+	htmlEscaping      bool
+	partialsDir       string
+	layout            string
+}
+
+func newParserConfig(opts []Option) (parserConfig, error) {
+	var cfg parserConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg, cfg.validate()
+}
+
+// validate checks that the configured options can be used together
+func (cfg parserConfig) validate() error {
+	if cfg.htmlEscaping && (cfg.format == ContentPlain || cfg.format == ContentMarkdown) {
+		return fmt.Errorf("%w: HTML escaping requires the %s or %s content format", errIncompatibleOptions, ContentAuto, ContentHTML)
+	}
+	if cfg.htmlEscaping && cfg.rawData {
+		return fmt.Errorf("%w: HTML escaping escapes data values, so they cannot be raw", errIncompatibleOptions)
+	}
+	return nil
+}
+
+// escapesOutput reports whether text templates HTML-escape the values they print.
+// Plain text and Markdown output is escaped as a whole when converted, and
+// html/template escapes values itself.
+func (cfg parserConfig) escapesOutput() bool {
+	return !cfg.rawData && !cfg.htmlEscaping && (cfg.format == ContentAuto || cfg.format == ContentHTML)
+}
+
+// WithMissingKeyPolicy sets how placeholders missing from recipient data are handled.
+// The default is MissingKeyFail.
+func WithMissingKeyPolicy(policy MissingKeyPolicy) Option {
+	return func(cfg *parserConfig) {
+		cfg.missingKey = policy
+	}
+}
+
+// WithMissingKeyDefault sets the value rendered for missing placeholders
+// under MissingKeyDefault. The default is an empty string.
+func WithMissingKeyDefault(value string) Option {
+	return func(cfg *parserConfig) {
+		cfg.missingKeyDefault = value
+	}
+}
+
+// WithCollectErrors makes TemplateParser.Parse render every recipient instead of
+// stopping at the first failure. All failures, whatever their cause, are returned
+// together in a *RenderReport alongside the successfully rendered messages.
+func WithCollectErrors() Option {
+	return func(cfg *parserConfig) {
+		cfg.collectErrors = true
+	}
+}
+
+// WithContentFormat sets how rendered output is converted into message HTML,
+// overriding the format given in template front matter. The default is ContentAuto.
+func WithContentFormat(format ContentFormat) Option {
+	return func(cfg *parserConfig) {
+		cfg.format = format
+		cfg.formatSet = true
+	}
+}
+
+// WithRawData disables HTML escaping of the values a template prints, for data files
+// that deliberately carry markup. Rendered messages are still sanitized.
+// It cannot be combined with WithHTMLEscaping.
+func WithRawData() Option {
+	return func(cfg *parserConfig) {
+		cfg.rawData = true
+	}
+}
+
+// WithHTMLEscaping parses the template with html/template, so placeholder values are
+// escaped according to where they appear: element text, attribute values or link URLs.
+// Trusted values can be inserted unescaped with the safe and safeURL functions.
+// It cannot be combined with the plain and Markdown content formats.
+func WithHTMLEscaping() Option {
+	return func(cfg *parserConfig) {
+		cfg.htmlEscaping = true
+	}
+}
+
+// WithPartials loads every template file (.tmpl, .tpl, .gotmpl, .html, .md, .txt)
+// under dir before the message template. Each file is available to the message as
+// a template named after its path relative to dir without extension, so
+// partials/footer.tmpl is included with {{template "footer" .}} and
+// partials/layouts/base.tmpl is named "layouts/base". Templates defined with
+// {{define}} inside the files are available too, and the message can redefine them.
+func WithPartials(dir string) Option {
+	return func(cfg *parserConfig) {
+		cfg.partialsDir = dir
+	}
+}
+
+// WithLayout renders messages through the named layout template, loaded with
+// WithPartials, overriding the layout given in template front matter. The message
+// template is available to the layout as "content", so a layout typically contains
+// {{template "content" .}}. Blocks declared by the layout with {{block}} can be
+// overridden by {{define}} in the message template.
+func WithLayout(name string) Option {
+	return func(cfg *parserConfig) {
+		cfg.layout = name
+	}
+}
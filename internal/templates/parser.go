@@ -3,9 +3,10 @@ package templates
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"io"
 	"regexp"
-	"text/template"
+	"strings"
 
 	"github.com/pzsp-teams/cli/internal/initializers"
 )
@@ -14,14 +15,23 @@ var htmlTagRegex = regexp.MustCompile(`</?[ibp]>|<br>|<a\s+href="[^"]*">|</a>`)
 
 // TemplateParser handles parsing different messages from supplied template and data
 type TemplateParser struct {
-	template   *template.Template
-	recipients map[string]TemplateData
+	template     *messageTemplate
+	placeholders [][]string
+	defaulted    [][]string
+	recipients   []Recipient
+	config       parserConfig
 }
 
 // NewMessageParser returns a MessageParser with given config.
 // It parses the template and data immediately, storing the parsed objects.
-func NewMessageParser(templateReader, dataReader io.Reader, dataParser Parser) (*TemplateParser, error) {
-	tmpl, err := readTemplate(templateReader)
+func NewMessageParser(templateReader, dataReader io.Reader, dataParser Parser, opts ...Option) (*TemplateParser, error) {
+	config, err := newParserConfig(opts)
+	if err != nil {
+		initializers.Logger.Error(errIncompatibleOptions.Error(), "error", err)
+		return nil, err
+	}
+
+	tmpl, err := readTemplate(templateReader, config)
 	if err != nil {
 		// readTemplate already logs and wraps the error
 		return nil, err
@@ -32,36 +42,124 @@ func NewMessageParser(templateReader, dataReader io.Reader, dataParser Parser) (
 		initializers.Logger.Error(errDataParseFailed.Error(), "error", err)
 		return nil, fmt.Errorf("%w: %w", errDataParseFailed, err)
 	}
+	if err := resolveTargets(recipients, tmpl.metadata.Target); err != nil {
+		initializers.Logger.Error(errInvalidTarget.Error(), "error", err)
+		return nil, err
+	}
 	initializers.Logger.Info("Message data parsed", "recipient_count", len(recipients))
 
 	return &TemplateParser{
-		template:   tmpl,
-		recipients: recipients,
+		template:     tmpl,
+		placeholders: collectPlaceholders(tmpl),
+		defaulted:    collectDefaulted(tmpl),
+		recipients:   recipients,
+		config:       tmpl.config,
 	}, nil
 }
 
-// Parse renders the template for each recipient and returns a map of rendered messages.
-// The map keys are recipient names, and values are the fully rendered messages.
-func (mp *TemplateParser) Parse() (map[string]string, error) {
-	messages := make(map[string]string, len(mp.recipients))
-	for recipientName, data := range mp.recipients {
-		var buf bytes.Buffer
-		if err := mp.template.Execute(&buf, data); err != nil {
-			initializers.Logger.Error(errTemplateRenderFailed.Error(), "recipient", recipientName, "error", err)
-			return nil, fmt.Errorf("%w for recipient %q: %w", errTemplateRenderFailed, recipientName, err)
+// Metadata returns the metadata read from the template front matter
+func (mp *TemplateParser) Metadata() Metadata {
+	return mp.template.metadata
+}
+
+// Parse renders the template for each recipient and returns the rendered messages
+// in the order recipients appear in the data. Besides the body, a message has a subject
+// and summary if the template defines "subject" and "summary" templates, directly
+// or through front matter. Messages are reduced to the HTML subset
+// Teams renders, and values printed by the template are HTML-escaped unless WithRawData is given.
+//
+// Under MissingKeySkip, recipients with missing placeholders are left out of the result
+// and a *RenderReport describing them is returned together with the remaining messages.
+// With WithCollectErrors, every failing recipient is reported that way.
+// Any other render failure is returned as a *RecipientError with no messages.
+func (mp *TemplateParser) Parse() ([]Message, error) {
+	messages := make([]Message, 0, len(mp.recipients))
+	report := &RenderReport{}
+	for _, recipient := range mp.recipients {
+		recipientName := recipient.Name
+		message, err := mp.render(recipientName, recipient.Data)
+		if err != nil {
+			if mp.config.collectErrors || (mp.config.missingKey == MissingKeySkip && err.MissingKey()) {
+				initializers.Logger.Warn("Skipping recipient that failed to render", "recipient", recipientName, "placeholder", err.Placeholder, "error", err.Err)
+				report.Failures = append(report.Failures, err)
+				continue
+			}
+			initializers.Logger.Error(errTemplateRenderFailed.Error(), "recipient", recipientName, "error", err.Err)
+			return nil, err
 		}
-		messages[recipientName] = processContent(buf.Bytes())
+		message.Target = recipient.Target
+		messages = append(messages, message)
+	}
+
+	if len(report.Failures) > 0 {
+		initializers.Logger.Warn("Rendered messages with failures", "total_messages", len(messages), "failed", len(report.Failures))
+		return messages, report
 	}
 
 	initializers.Logger.Info("Successfully rendered messages", "total_messages", len(messages))
 	return messages, nil
 }
 
-func processContent(data []byte) string {
-	if htmlTagRegex.Match(data) {
+// render executes the template parts for a single recipient
+func (mp *TemplateParser) render(recipientName string, data TemplateData) (Message, *RecipientError) {
+	// missing keys fail before default is called, so they are made present but nil
+	data = fillMissing(data, mp.defaulted, nil)
+	if mp.config.missingKey == MissingKeyDefault {
+		data = fillMissing(data, mp.placeholders, mp.config.missingKeyDefault)
+	}
+
+	var buf bytes.Buffer
+	if err := mp.template.Execute(&buf, data); err != nil {
+		return Message{}, newRecipientError(recipientName, err, mp.template)
+	}
+
+	body, err := sanitizeHTML(processContent(buf.Bytes(), mp.config.format))
+	if err != nil {
+		return Message{}, newRecipientError(recipientName, err, mp.template)
+	}
+
+	message := Message{Recipient: recipientName, Body: body}
+	if message.Subject, err = mp.renderText(subjectTemplate, data); err != nil {
+		return Message{}, newRecipientError(recipientName, err, mp.template)
+	}
+	if message.Summary, err = mp.renderText(summaryTemplate, data); err != nil {
+		return Message{}, newRecipientError(recipientName, err, mp.template)
+	}
+	return message, nil
+}
+
+// renderText executes the named template, if defined, as a plain text message part.
+// HTML escaping applied to printed values is undone, and whitespace,
+// including line breaks, collapses into single spaces.
+func (mp *TemplateParser) renderText(name string, data TemplateData) (string, error) {
+	if !mp.template.defines(name) {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := mp.template.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(buf.String())), " "), nil
+}
+
+// processContent converts rendered template output into message HTML according to format
+func processContent(data []byte, format ContentFormat) string {
+	switch format {
+	case ContentHTML:
 		return string(data)
+	case ContentMarkdown:
+		return renderMarkdown(string(data))
+	case ContentPlain:
+		return lineBreaksToHTML([]byte(html.EscapeString(string(data))))
+	default:
+		if htmlTagRegex.Match(data) {
+			return string(data)
+		}
+		return lineBreaksToHTML(data)
 	}
+}
 
+func lineBreaksToHTML(data []byte) string {
 	// \r can be ignored, won't be rendered by html on teams anyway
 	replaced := bytes.ReplaceAll(data, []byte("\n"), []byte("<br>"))
 	return string(replaced)
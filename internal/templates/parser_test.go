@@ -1,6 +1,8 @@
 package templates
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -25,7 +27,7 @@ func TestMessageParser_JSONFormatMultipleRecipients(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -82,7 +84,7 @@ See you there!`
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -124,7 +126,7 @@ bob:
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -150,6 +152,65 @@ bob:
 	}
 }
 
+func TestMessageParser_YAMLKeepsScalarText(t *testing.T) {
+	template := "{{.zip}} {{.price}} {{.version}} {{.count}} {{.active}} {{index .tags 0}} {{.base.code}}"
+	data := `
+alice:
+  defaults: &base
+    code: 007
+  zip: 01234
+  price: 10.50
+  version: 1.10
+  count: 42
+  active: true
+  tags: [0.50]
+  base:
+    <<: *base
+`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &YAMLParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	if got, want := messages["alice"], "01234 10.50 1.10 42 true 0.50 007"; got != want {
+		t.Errorf("MessageParser.Parse() got %q, want %q", got, want)
+	}
+}
+
+func TestMessageParser_BlankValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		parser Parser
+	}{
+		{name: "YAML null", data: "alice:\n  x: null\n  y: ~\n", parser: &YAMLParser{}},
+		{name: "empty YAML scalar", data: "alice:\n  x:\n  y: \"\"\n", parser: &YAMLParser{}},
+		{name: "JSON null", data: `{"alice": {"x": null, "y": {"z": null}}}`, parser: &JSONParser{}},
+		{name: "empty CSV cell", data: "name,x,y\nalice,,\n", parser: &CSVParser{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader("[{{.x}}]"), strings.NewReader(tt.data), tt.parser)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := parseByRecipient(mp)
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if got := messages["alice"]; got != "[]" {
+				t.Errorf("MessageParser.Parse() got %q, want %q", got, "[]")
+			}
+		})
+	}
+}
+
 func TestMessageParser_TOMLFormat(t *testing.T) {
 	template := "Hello {{.name}}!"
 	data := `
@@ -167,7 +228,7 @@ name = "Bob"
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -267,7 +328,7 @@ func TestMessageParser_MissingPlaceholderInData(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	_, err = mp.Parse()
+	_, err = parseByRecipient(mp)
 
 	if err == nil {
 		t.Error("MessageParser.Parse() expected error for missing placeholder, got nil")
@@ -282,7 +343,7 @@ func TestMessageParser_EmptyData(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Errorf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -308,7 +369,7 @@ func TestMessageParser_PlainTextWithCRLF(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -337,7 +398,7 @@ func TestMessageParser_HTMLContentPreserved(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -366,7 +427,7 @@ func TestMessageParser_HTMLContentWithCRLF(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -394,7 +455,7 @@ func TestMessageParser_HTMLWithBoldAndItalic(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -422,7 +483,7 @@ func TestMessageParser_HTMLWithLinks(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -450,7 +511,7 @@ func TestMessageParser_HTMLWithBRTag(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -478,7 +539,7 @@ func TestMessageParser_MixedCRLFAndLF(t *testing.T) {
 	if err != nil {
 		t.Fatalf("NewMessageParser() unexpected error: %v", err)
 	}
-	messages, err := mp.Parse()
+	messages, err := parseByRecipient(mp)
 	if err != nil {
 		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
 	}
@@ -490,3 +551,405 @@ func TestMessageParser_MixedCRLFAndLF(t *testing.T) {
 		t.Errorf("MessageParser.Parse() for mixed CRLF and LF:\ngot:\n%q\nwant:\n%q", gotMsg, wantMsg)
 	}
 }
+
+func TestMessageParser_StructuredData(t *testing.T) {
+	template := `{{.name}} ({{.team.name}}){{if .active}} active{{end}}{{if gt .count 1}}, {{.count}} items{{end}}:{{range .agenda}} [{{.}}]{{end}}`
+	want := "Alice (Alpha) active, 2 items: [Intro] [Review]"
+
+	tests := []struct {
+		name   string
+		data   string
+		parser Parser
+	}{
+		{
+			name:   "json",
+			data:   `{"alice": {"name": "Alice", "team": {"name": "Alpha"}, "active": true, "count": 2, "agenda": ["Intro", "Review"]}}`,
+			parser: &JSONParser{},
+		},
+		{
+			name: "yaml",
+			data: `
+alice:
+  name: Alice
+  team:
+    name: Alpha
+  active: true
+  count: 2
+  agenda:
+    - Intro
+    - Review
+`,
+			parser: &YAMLParser{},
+		},
+		{
+			name: "toml",
+			data: `
+[alice]
+name = "Alice"
+active = true
+count = 2
+agenda = ["Intro", "Review"]
+
+[alice.team]
+name = "Alpha"
+`,
+			parser: &TOMLParser{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(tt.data), tt.parser)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := parseByRecipient(mp)
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages["alice"] != want {
+				t.Errorf("MessageParser.Parse() got %q, want %q", messages["alice"], want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_JSONNumbers(t *testing.T) {
+	template := "{{.order}} {{.price}} {{.id}}"
+	data := `{"alice": {"order": 12345, "price": 9.5, "id": "007"}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	if want := "12345 9.5 007"; messages["alice"] != want {
+		t.Errorf("MessageParser.Parse() got %q, want %q", messages["alice"], want)
+	}
+}
+
+func TestMessageParser_DefaultsSection(t *testing.T) {
+	template := "Hi {{.name}}, meeting on {{.date}} at {{.room.name}} ({{.room.floor}}). {{.sender}}"
+	want := map[string]string{
+		"alice": "Hi Alice, meeting on Monday at Blue (2). Bob",
+		"carol": "Hi Carol, meeting on Tuesday at Red (2). Bob",
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		parser Parser
+	}{
+		{
+			name: "json",
+			data: `{
+				"_defaults": {"date": "Monday", "sender": "Bob", "room": {"name": "Blue", "floor": 2}},
+				"alice": {"name": "Alice"},
+				"carol": {"name": "Carol", "date": "Tuesday", "room": {"name": "Red"}}
+			}`,
+			parser: &JSONParser{},
+		},
+		{
+			name: "yaml",
+			data: `
+_defaults:
+  date: Monday
+  sender: Bob
+  room:
+    name: Blue
+    floor: 2
+alice:
+  name: Alice
+carol:
+  name: Carol
+  date: Tuesday
+  room:
+    name: Red
+`,
+			parser: &YAMLParser{},
+		},
+		{
+			name: "toml",
+			data: `
+[_defaults]
+date = "Monday"
+sender = "Bob"
+room = { name = "Blue", floor = 2 }
+
+[alice]
+name = "Alice"
+
+[carol]
+name = "Carol"
+date = "Tuesday"
+room = { name = "Red" }
+`,
+			parser: &TOMLParser{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(tt.data), tt.parser)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := parseByRecipient(mp)
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+
+			if len(messages) != len(want) {
+				t.Errorf("MessageParser.Parse() got %d messages, want %d", len(messages), len(want))
+			}
+			for recipient, wantMsg := range want {
+				if messages[recipient] != wantMsg {
+					t.Errorf("MessageParser.Parse() for recipient %q got %q, want %q", recipient, messages[recipient], wantMsg)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageParser_MissingKeyFailReportsRecipient(t *testing.T) {
+	template := "Hello {{.name}}! Your email is {{.email}}"
+	data := `{"alice": {"name": "Alice"}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+
+	var recipientErr *RecipientError
+	if !errors.As(err, &recipientErr) {
+		t.Fatalf("MessageParser.Parse() error = %v, want *RecipientError", err)
+	}
+	if !errors.Is(err, errTemplateRenderFailed) {
+		t.Errorf("MessageParser.Parse() error = %v, want wrapped %v", err, errTemplateRenderFailed)
+	}
+	if messages != nil {
+		t.Errorf("MessageParser.Parse() got %d messages, want nil", len(messages))
+	}
+	if recipientErr.Recipient != "alice" || recipientErr.Placeholder != ".email" || recipientErr.Line != 1 || !recipientErr.MissingKey() {
+		t.Errorf("MessageParser.Parse() error = %+v", recipientErr)
+	}
+}
+
+func TestMessageParser_MissingKeySkip(t *testing.T) {
+	template := "Hello {{.name}}!\nYour email is {{.email}}"
+	data := `{
+		"alice": {"name": "Alice", "email": "alice@example.com"},
+		"bob": {"name": "Bob"},
+		"carol": {"email": "carol@example.com"}
+	}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{},
+		WithMissingKeyPolicy(MissingKeySkip))
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+
+	var report *RenderReport
+	if !errors.As(err, &report) {
+		t.Fatalf("MessageParser.Parse() error = %v, want *RenderReport", err)
+	}
+	if len(messages) != 1 || messages["alice"] != "Hello Alice!<br>Your email is alice@example.com" {
+		t.Errorf("MessageParser.Parse() messages = %v", messages)
+	}
+
+	if len(report.Failures) != 2 {
+		t.Fatalf("RenderReport got %d failures, want 2", len(report.Failures))
+	}
+	bob, carol := report.Failures[0], report.Failures[1]
+	if bob.Recipient != "bob" || bob.Placeholder != ".email" || bob.Line != 2 {
+		t.Errorf("RenderReport failure = %+v, want bob at .email on line 2", bob)
+	}
+	if carol.Recipient != "carol" || carol.Placeholder != ".name" || carol.Line != 1 {
+		t.Errorf("RenderReport failure = %+v, want carol at .name on line 1", carol)
+	}
+	if !strings.Contains(report.Error(), `"bob" at <.email> (line 2`) {
+		t.Errorf("RenderReport.Error() = %q", report.Error())
+	}
+}
+
+func TestMessageParser_MissingKeySkipStillFailsOnOtherErrors(t *testing.T) {
+	template := `{{formatNumber 2 .amount}}`
+	data := `{"alice": {"amount": "lots"}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{},
+		WithMissingKeyPolicy(MissingKeySkip))
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	_, err = parseByRecipient(mp)
+
+	var recipientErr *RecipientError
+	if !errors.As(err, &recipientErr) {
+		t.Errorf("MessageParser.Parse() error = %v, want *RecipientError", err)
+	}
+}
+
+func TestMessageParser_MissingKeyDefault(t *testing.T) {
+	template := "Hi {{.name}} from {{.team.name}}{{with .room}} in {{.name}}{{end}}, {{$.signature}}"
+	data := `{
+		"alice": {"name": "Alice", "team": {"name": "Alpha"}, "room": {"name": "Blue"}, "signature": "Bob"},
+		"carol": {"name": "Carol", "room": {}}
+	}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{},
+		WithMissingKeyPolicy(MissingKeyDefault), WithMissingKeyDefault("?"))
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"alice": "Hi Alice from Alpha in Blue, Bob",
+		"carol": "Hi Carol from ? in ?, ?",
+	}
+	for recipient, wantMsg := range want {
+		if messages[recipient] != wantMsg {
+			t.Errorf("MessageParser.Parse() for recipient %q got %q, want %q", recipient, messages[recipient], wantMsg)
+		}
+	}
+}
+
+func TestParseMissingKeyPolicy(t *testing.T) {
+	for _, policy := range []MissingKeyPolicy{MissingKeyFail, MissingKeySkip, MissingKeyDefault} {
+		got, err := ParseMissingKeyPolicy(policy.String())
+		if err != nil || got != policy {
+			t.Errorf("ParseMissingKeyPolicy(%q) = %v, %v", policy.String(), got, err)
+		}
+	}
+	if _, err := ParseMissingKeyPolicy("ignore"); !errors.Is(err, errUnknownMissingKeyPolicy) {
+		t.Errorf("ParseMissingKeyPolicy() error = %v, want %v", err, errUnknownMissingKeyPolicy)
+	}
+}
+
+func TestMessageParser_CollectErrors(t *testing.T) {
+	template := "Hello {{.name}}!\nTotal: {{formatNumber 2 .amount}}\n{{.email}}"
+	data := `{
+		"alice": {"name": "Alice", "amount": 10, "email": "alice@example.com"},
+		"bob": {"name": "Bob", "amount": "lots", "email": "bob@example.com"},
+		"carol": {"name": "Carol", "amount": 5},
+		"dave": {"name": "Dave", "amount": 1, "email": "dave@example.com"}
+	}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, WithCollectErrors())
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := parseByRecipient(mp)
+
+	var report *RenderReport
+	if !errors.As(err, &report) {
+		t.Fatalf("MessageParser.Parse() error = %v, want *RenderReport", err)
+	}
+	if !errors.Is(err, errTemplateRenderFailed) {
+		t.Errorf("MessageParser.Parse() error = %v, want wrapped %v", err, errTemplateRenderFailed)
+	}
+
+	if len(messages) != 2 || messages["alice"] == "" || messages["dave"] == "" {
+		t.Errorf("MessageParser.Parse() messages = %v, want alice and dave", messages)
+	}
+
+	want := []struct {
+		recipient   string
+		placeholder string
+		line        int
+	}{
+		{recipient: "bob", placeholder: "formatNumber 2 .amount", line: 2},
+		{recipient: "carol", placeholder: ".email", line: 3},
+	}
+	if len(report.Failures) != len(want) {
+		t.Fatalf("RenderReport got %d failures, want %d", len(report.Failures), len(want))
+	}
+	for i, w := range want {
+		got := report.Failures[i]
+		if got.Recipient != w.recipient || got.Placeholder != w.placeholder || got.Line != w.line {
+			t.Errorf("RenderReport failure %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestMessageParser_PreservesRecipientOrder(t *testing.T) {
+	template := "{{.n}}"
+	want := []Message{
+		{Recipient: "zoe", Body: "1"},
+		{Recipient: "alice", Body: "2"},
+		{Recipient: "mike", Body: "3"},
+		{Recipient: "bob", Body: "4"},
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		parser Parser
+	}{
+		{
+			name:   "json",
+			data:   `{"zoe": {"n": 1}, "alice": {"n": 2}, "_defaults": {"n": 0}, "mike": {"n": 3}, "bob": {"n": 4}}`,
+			parser: &JSONParser{},
+		},
+		{
+			name:   "yaml",
+			data:   "zoe: {n: 1}\nalice: {n: 2}\n_defaults: {n: 0}\nmike: {n: 3}\nbob: {n: 4}\n",
+			parser: &YAMLParser{},
+		},
+		{
+			name:   "toml",
+			data:   "zoe = { n = 1 }\n[alice]\nn = 2\n[mike.extra]\nx = 1\n[mike]\nn = 3\n[_defaults]\nn = 0\n[bob]\nn = 4\n",
+			parser: &TOMLParser{},
+		},
+		{
+			name:   "csv",
+			data:   "name,n\nzoe,1\nalice,2\nmike,3\nbob,4\n",
+			parser: &CSVParser{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 5 {
+				mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(tt.data), tt.parser)
+				if err != nil {
+					t.Fatalf("NewMessageParser() unexpected error: %v", err)
+				}
+				messages, err := mp.Parse()
+				if err != nil {
+					t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(messages, want) {
+					t.Fatalf("MessageParser.Parse() = %v, want %v", messages, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageParser_DuplicateJSONRecipient(t *testing.T) {
+	data := `{"alice": {"name": "Alice"}, "alice": {"name": "Alicia"}}`
+
+	_, err := NewMessageParser(strings.NewReader("{{.name}}"), strings.NewReader(data), &JSONParser{})
+
+	if !errors.Is(err, errDuplicateRecipient) {
+		t.Errorf("NewMessageParser() error = %v, want wrapped %v", err, errDuplicateRecipient)
+	}
+}
+
+func TestMessageParser_JSONNotAnObject(t *testing.T) {
+	_, err := NewMessageParser(strings.NewReader("{{.name}}"), strings.NewReader(`[{"name": "Alice"}]`), &JSONParser{})
+
+	if !errors.Is(err, errJSONDecodeFailed) {
+		t.Errorf("NewMessageParser() error = %v, want wrapped %v", err, errJSONDecodeFailed)
+	}
+}
@@ -0,0 +1,63 @@
+package templates
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pzsp-teams/cli/internal/initializers"
+)
+
+// partialExtensions lists the file extensions loaded as partials and layouts
+var partialExtensions = map[string]bool{
+	".tmpl":   true,
+	".tpl":    true,
+	".gotmpl": true,
+	".html":   true,
+	".md":     true,
+	".txt":    true,
+}
+
+// partial is a template file loaded from a partials directory
+type partial struct {
+	// name is the slash-separated path relative to the directory without extension, e.g. "layouts/base"
+	name string
+	// file is the path of the file, used in error messages
+	file    string
+	content string
+}
+
+// loadPartials reads every template file under dir, in lexical order
+func loadPartials(dir string) ([]partial, error) {
+	var partials []partial
+	fsys := os.DirFS(dir)
+	err := fs.WalkDir(fsys, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := path.Ext(p)
+		if entry.IsDir() || !partialExtensions[ext] {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		partials = append(partials, partial{
+			name:    strings.TrimSuffix(p, ext),
+			file:    path.Join(dir, p),
+			content: string(content),
+		})
+		return nil
+	})
+	if err != nil {
+		initializers.Logger.Error(errPartialsReadFailed.Error(), "dir", dir, "error", err)
+		return nil, fmt.Errorf("%w %s: %w", errPartialsReadFailed, dir, err)
+	}
+
+	initializers.Logger.Debug("Partials loaded", "dir", dir, "count", len(partials))
+	return partials, nil
+}
@@ -0,0 +1,170 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePartials creates the given files under a temporary directory and returns it
+func writePartials(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestMessageParser_Partials(t *testing.T) {
+	dir := writePartials(t, map[string]string{
+		"footer.tmpl":      "-- {{.sender}}",
+		"signature.md":     `{{define "sig"}}Regards, {{.sender}}{{end}}`,
+		"README":           "not a template {{",
+		"shared/greet.txt": "Hello {{.name}}",
+	})
+	template := `{{template "shared/greet" .}}! {{template "sig" .}} {{template "footer" .}}`
+	data := `{"alice": {"name": "Alice", "sender": "Bob"}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, WithPartials(dir))
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := mp.Parse()
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	if want := "Hello Alice! Regards, Bob -- Bob"; messages[0].Body != want {
+		t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, want)
+	}
+}
+
+func TestMessageParser_Layout(t *testing.T) {
+	dir := writePartials(t, map[string]string{
+		"layouts/base.tmpl": `<h1>{{block "title" .}}Announcement{{end}}</h1><p>{{template "content" .}}</p>{{template "footer" .}}`,
+		"footer.tmpl":       `<p>-- {{.sender}}</p>`,
+	})
+	data := `{"alice": {"name": "Alice", "sender": "Bob"}}`
+
+	tests := []struct {
+		name     string
+		template string
+		opts     []Option
+		want     string
+	}{
+		{
+			name:     "default block",
+			template: "Hi {{.name}}",
+			want:     "<h1>Announcement</h1><p>Hi Alice</p><p>-- Bob</p>",
+		},
+		{
+			name:     "overridden block",
+			template: `{{define "title"}}Reminder for {{.name}}{{end}}Hi {{.name}}`,
+			want:     "<h1>Reminder for Alice</h1><p>Hi Alice</p><p>-- Bob</p>",
+		},
+		{
+			name:     "html escaping",
+			template: `{{define "title"}}Reminder{{end}}Hi {{.name}}`,
+			opts:     []Option{WithHTMLEscaping()},
+			want:     "<h1>Reminder</h1><p>Hi Alice</p><p>-- Bob</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithPartials(dir), WithLayout("layouts/base")}, tt.opts...)
+			mp, err := NewMessageParser(strings.NewReader(tt.template), strings.NewReader(data), &JSONParser{}, opts...)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages[0].Body != tt.want {
+				t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_PartialSyntaxErrorNamesFile(t *testing.T) {
+	dir := writePartials(t, map[string]string{"footer.tmpl": "-- {{.sender"})
+
+	_, err := NewMessageParser(strings.NewReader(`{{template "footer" .}}`), strings.NewReader(`{}`), &JSONParser{}, WithPartials(dir))
+
+	if !errors.Is(err, errTemplateParseFailed) {
+		t.Fatalf("NewMessageParser() error = %v, want %v", err, errTemplateParseFailed)
+	}
+	if !strings.Contains(err.Error(), filepath.Join(dir, "footer.tmpl")) {
+		t.Errorf("NewMessageParser() error = %q, want it to name footer.tmpl", err)
+	}
+}
+
+func TestMessageParser_LayoutNotFound(t *testing.T) {
+	dir := writePartials(t, map[string]string{"footer.tmpl": "--"})
+
+	_, err := NewMessageParser(strings.NewReader("Hi"), strings.NewReader(`{}`), &JSONParser{}, WithPartials(dir), WithLayout("base"))
+
+	if !errors.Is(err, errLayoutNotFound) {
+		t.Errorf("NewMessageParser() error = %v, want %v", err, errLayoutNotFound)
+	}
+}
+
+func TestMessageParser_MissingPartialsDir(t *testing.T) {
+	_, err := NewMessageParser(strings.NewReader("Hi"), strings.NewReader(`{}`), &JSONParser{},
+		WithPartials(filepath.Join(t.TempDir(), "missing")))
+
+	if !errors.Is(err, errPartialsReadFailed) {
+		t.Errorf("NewMessageParser() error = %v, want %v", err, errPartialsReadFailed)
+	}
+}
+
+func TestLint_Partials(t *testing.T) {
+	dir := writePartials(t, map[string]string{"footer.tmpl": "-- {{.sender}}"})
+
+	report, err := Lint(strings.NewReader(`Hi {{.name}} {{template "footer" .}}`), strings.NewReader(`{"alice": {"name": "A"}}`),
+		&JSONParser{}, WithPartials(dir))
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].Placeholders[0] != "sender" {
+		t.Errorf("Lint() Missing = %v, want sender missing", report.Missing)
+	}
+}
+
+func TestLint_UnusedPartialsNotRequired(t *testing.T) {
+	dir := writePartials(t, map[string]string{"footer.tmpl": "-- {{.sender}}", "header.tmpl": "{{.title}}"})
+
+	report, err := Lint(strings.NewReader(`Hi {{.name}} {{template "footer" .}}`), strings.NewReader(`{"alice": {"name": "A", "sender": "B"}}`),
+		&JSONParser{}, WithPartials(dir))
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+	if report.HasProblems() {
+		t.Errorf("Lint() Missing = %v, want none", report.Missing)
+	}
+}
+
+func TestLint_PartialSyntaxError(t *testing.T) {
+	dir := writePartials(t, map[string]string{"footer.tmpl": "ok\n-- {{.sender}"})
+
+	report, err := Lint(strings.NewReader(`{{template "footer" .}}`), strings.NewReader(`{}`), &JSONParser{}, WithPartials(dir))
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+
+	want := SyntaxError{File: filepath.Join(dir, "footer.tmpl"), Line: 2, Column: 13, Message: "bad character U+007D '}'"}
+	if len(report.SyntaxErrors) != 1 || report.SyntaxErrors[0] != want {
+		t.Errorf("Lint() SyntaxErrors = %+v, want %+v", report.SyntaxErrors, want)
+	}
+}
@@ -0,0 +1,87 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageParser_MessageParts(t *testing.T) {
+	data := `{"alice": {"name": "Alice & Bob", "count": 3}}`
+
+	tests := []struct {
+		name     string
+		template string
+		opts     []Option
+		want     Message
+	}{
+		{
+			name:     "body only",
+			template: "Hi {{.name}}",
+			want:     Message{Recipient: "alice", Body: "Hi Alice &amp; Bob"},
+		},
+		{
+			name:     "defined parts",
+			template: "{{define \"subject\"}}\n  Update for {{.name}}\n{{end}}{{define \"summary\"}}{{.count}} new items{{end}}<p>Hi {{.name}}</p>",
+			want:     Message{Recipient: "alice", Subject: "Update for Alice & Bob", Body: "<p>Hi Alice &amp; Bob</p>", Summary: "3 new items"},
+		},
+		{
+			name:     "front matter parts",
+			template: "---\nsubject: \"Update for {{.name}}\"\nsummary: \"{{.count}} new items\"\n---\nHi",
+			want:     Message{Recipient: "alice", Subject: "Update for Alice & Bob", Body: "Hi", Summary: "3 new items"},
+		},
+		{
+			name:     "template overrides front matter",
+			template: "---\nsubject: From front matter\n---\n{{define \"subject\"}}From template{{end}}Hi",
+			want:     Message{Recipient: "alice", Subject: "From template", Body: "Hi"},
+		},
+		{
+			name:     "html escaping",
+			template: `{{define "subject"}}<b>{{.name}}</b>{{end}}Hi`,
+			opts:     []Option{WithHTMLEscaping()},
+			want:     Message{Recipient: "alice", Subject: "<b>Alice & Bob</b>", Body: "Hi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(tt.template), strings.NewReader(data), &JSONParser{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages[0] != tt.want {
+				t.Errorf("MessageParser.Parse() got %+v, want %+v", messages[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_MessagePartMissingKey(t *testing.T) {
+	template := `{{define "subject"}}For {{.team}}{{end}}Hi {{.name}}`
+	data := `{"alice": {"name": "Alice"}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	_, err = mp.Parse()
+
+	var recipientErr *RecipientError
+	if !errors.As(err, &recipientErr) || recipientErr.Placeholder != ".team" {
+		t.Errorf("MessageParser.Parse() error = %v, want missing team placeholder", err)
+	}
+}
+
+func TestMessageParser_FrontMatterPartSyntaxError(t *testing.T) {
+	template := "---\nsubject: \"{{.name\"\n---\nHi"
+
+	_, err := NewMessageParser(strings.NewReader(template), strings.NewReader(`{}`), &JSONParser{})
+
+	if !errors.Is(err, errFrontMatterInvalid) {
+		t.Errorf("NewMessageParser() error = %v, want %v", err, errFrontMatterInvalid)
+	}
+}
@@ -0,0 +1,264 @@
+package templates
+
+import (
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// collectPlaceholders returns the field paths, such as ["room", "name"] for {{.room.name}},
+// that tmpl evaluates against the recipient data. Fields inside {{range}} bodies are
+// relative to list elements and are not included. The result is sorted and de-duplicated.
+func collectPlaceholders(tmpl *messageTemplate) [][]string {
+	seen := make(map[string][]string)
+	walkTemplate(tmpl, func(path []string, _ bool) {
+		seen[strings.Join(path, ".")] = path
+	})
+	return sortedPaths(seen)
+}
+
+// collectDefaulted returns the field paths that tmpl only passes to default,
+// as in {{default "none" .nickname}}, so their absence is handled by the template
+func collectDefaulted(tmpl *messageTemplate) [][]string {
+	defaulted := make(map[string][]string)
+	required := make(map[string]bool)
+	walkTemplate(tmpl, func(path []string, isDefaulted bool) {
+		key := strings.Join(path, ".")
+		if isDefaulted {
+			defaulted[key] = path
+		} else {
+			required[key] = true
+		}
+	})
+	for key := range required {
+		delete(defaulted, key)
+	}
+	return sortedPaths(defaulted)
+}
+
+// walkTemplate visits the templates tmpl executes for a message: the entry template,
+// the subject and summary, and the templates they include. Field paths are reported through add.
+func walkTemplate(tmpl *messageTemplate, add func(path []string, defaulted bool)) {
+	w := &placeholderWalker{
+		lookup: tmpl.tree,
+		add:    add,
+		active: make(map[string]bool),
+		walked: make(map[string]bool),
+	}
+	for _, name := range []string{tmpl.entry, subjectTemplate, summaryTemplate} {
+		w.template(name, []string{})
+	}
+}
+
+// sortedPaths returns the paths of seen ordered by their keys
+func sortedPaths(seen map[string][]string) [][]string {
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	paths := make([][]string, len(keys))
+	for i, key := range keys {
+		paths[i] = seen[key]
+	}
+	return paths
+}
+
+// placeholderWalker reports the field paths evaluated by a template and the
+// templates it includes
+type placeholderWalker struct {
+	lookup func(name string) *parse.Tree
+	add    func(path []string, defaulted bool)
+	// root is the path of $ in the template being walked, or nil if unknown
+	root []string
+	// active holds the templates being walked, so recursive templates are visited once
+	active map[string]bool
+	// walked holds the templates already walked, keyed by name and dot
+	walked map[string]bool
+}
+
+// template walks the named template, if defined, executed with dot
+func (w *placeholderWalker) template(name string, dot []string) {
+	tree := w.lookup(name)
+	key := name + "\x00" + strings.Join(dot, ".")
+	if dot == nil {
+		key = name + "\x00?"
+	}
+	if tree == nil || tree.Root == nil || w.active[name] || w.walked[key] {
+		return
+	}
+	w.active[name], w.walked[key] = true, true
+	defer delete(w.active, name)
+
+	callee := *w
+	callee.root = dot
+	callee.node(tree.Root, dot)
+}
+
+// node visits node, reporting field paths through add.
+// dot is the path of the current dot relative to the data root, or nil if unknown.
+func (w *placeholderWalker) node(node parse.Node, dot []string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		w.list(n, dot)
+	case *parse.ActionNode:
+		w.pipe(n.Pipe, dot)
+	case *parse.TemplateNode:
+		w.pipe(n.Pipe, dot)
+		w.template(n.Name, pipeFieldPath(n.Pipe, dot))
+	case *parse.PipeNode:
+		w.pipe(n, dot)
+	case *parse.CommandNode:
+		w.command(n, dot)
+	case *parse.FieldNode:
+		w.field(n, dot)
+	case *parse.VariableNode:
+		w.variable(n)
+	case *parse.IfNode:
+		w.branch(&n.BranchNode, dot, dot)
+	case *parse.WithNode:
+		w.branch(&n.BranchNode, dot, pipeFieldPath(n.Pipe, dot))
+	case *parse.RangeNode:
+		w.branch(&n.BranchNode, dot, nil)
+	}
+}
+
+// list visits the nodes of list in order
+func (w *placeholderWalker) list(list *parse.ListNode, dot []string) {
+	if list == nil {
+		return
+	}
+	for _, child := range list.Nodes {
+		w.node(child, dot)
+	}
+}
+
+// field reports the path of a field evaluated against a known dot
+func (w *placeholderWalker) field(field *parse.FieldNode, dot []string) {
+	if dot != nil {
+		w.add(appendPath(dot, field.Ident), false)
+	}
+}
+
+// variable reports the path of a field of $, such as {{$.organizer.name}}
+func (w *placeholderWalker) variable(variable *parse.VariableNode) {
+	if w.root != nil && len(variable.Ident) > 1 && variable.Ident[0] == "$" {
+		w.add(appendPath(w.root, variable.Ident[1:]), false)
+	}
+}
+
+// pipe visits the commands of pipe. A single field piped into default,
+// as in {{.nickname | default "none"}}, is reported as defaulted.
+func (w *placeholderWalker) pipe(pipe *parse.PipeNode, dot []string) {
+	if pipe == nil {
+		return
+	}
+	for i, cmd := range pipe.Cmds {
+		if i+1 < len(pipe.Cmds) && isDefaultCall(pipe.Cmds[i+1]) && len(cmd.Args) == 1 && w.defaulted(cmd.Args[0], dot) {
+			continue
+		}
+		w.node(cmd, dot)
+	}
+}
+
+// command visits the arguments of cmd. A field given as the last
+// argument of default, as in {{default "none" .nickname}}, is reported as defaulted.
+func (w *placeholderWalker) command(cmd *parse.CommandNode, dot []string) {
+	for i, arg := range cmd.Args {
+		if i == len(cmd.Args)-1 && i > 0 && isDefaultCall(cmd) && w.defaulted(arg, dot) {
+			continue
+		}
+		w.node(arg, dot)
+	}
+}
+
+// defaulted reports node as defaulted if it is a field with a known path
+func (w *placeholderWalker) defaulted(node parse.Node, dot []string) bool {
+	field, ok := node.(*parse.FieldNode)
+	if !ok || dot == nil {
+		return false
+	}
+	w.add(appendPath(dot, field.Ident), true)
+	return true
+}
+
+// branch visits the pipeline and else branch with dot, and the body with bodyDot
+func (w *placeholderWalker) branch(n *parse.BranchNode, dot, bodyDot []string) {
+	w.node(n.Pipe, dot)
+	w.node(n.List, bodyDot)
+	w.node(n.ElseList, dot)
+}
+
+// isDefaultCall reports whether cmd calls the default function
+func isDefaultCall(cmd *parse.CommandNode) bool {
+	if len(cmd.Args) == 0 {
+		return false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && ident.Ident == "default"
+}
+
+// pipeFieldPath returns the data path of a pipeline consisting of dot or a single field,
+// such as {{with .room}} or {{template "footer" .}}
+func pipeFieldPath(pipe *parse.PipeNode, dot []string) []string {
+	if dot == nil || pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return appendPath(dot, arg.Ident)
+	default:
+		return nil
+	}
+}
+
+func appendPath(prefix, ident []string) []string {
+	path := make([]string, 0, len(prefix)+len(ident))
+	path = append(path, prefix...)
+	return append(path, ident...)
+}
+
+// fillMissing returns data with every path that is absent set to value.
+// Maps along modified paths are copied, so data itself is never changed.
+func fillMissing(data TemplateData, paths [][]string, value any) TemplateData {
+	filled := map[string]any(data)
+	for _, path := range paths {
+		filled, _ = withMissing(filled, path, value)
+	}
+	return filled
+}
+
+// withMissing sets path in m to value if absent, reporting whether a copy was made
+func withMissing(m map[string]any, path []string, value any) (map[string]any, bool) {
+	current, ok := m[path[0]]
+	if ok && len(path) == 1 {
+		return m, false
+	}
+
+	var replacement any = value
+	if len(path) > 1 {
+		nested, isMap := current.(map[string]any)
+		if ok && !isMap {
+			// a non-map value cannot hold nested fields; leave it for the template to report
+			return m, false
+		}
+		if !ok {
+			nested = map[string]any{}
+		}
+		updated, changed := withMissing(nested, path[1:], value)
+		if ok && !changed {
+			return m, false
+		}
+		replacement = updated
+	}
+
+	copied := make(map[string]any, len(m)+1)
+	for key, v := range m {
+		copied[key] = v
+	}
+	copied[path[0]] = replacement
+	return copied, true
+}
@@ -0,0 +1,87 @@
+package templates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectPlaceholders(t *testing.T) {
+	content := `{{.name | upper}} {{if .vip}}{{.title}}{{else}}{{.nickname}}{{end}}` +
+		`{{with .room}}{{.name}} {{.floor}}{{end}}` +
+		`{{range .agenda}}{{.topic}} {{$.organizer.name}}{{end}}` +
+		`{{define "footer"}}{{.signature}}{{end}}{{template "footer" .}}{{.name}}`
+	tmpl, err := readTemplate(strings.NewReader(content), parserConfig{})
+	if err != nil {
+		t.Fatalf("readTemplate() unexpected error: %v", err)
+	}
+
+	got := collectPlaceholders(tmpl)
+
+	want := [][]string{
+		{"agenda"},
+		{"name"},
+		{"nickname"},
+		{"organizer", "name"},
+		{"room"},
+		{"room", "floor"},
+		{"room", "name"},
+		{"signature"},
+		{"title"},
+		{"vip"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectPlaceholders() = %v, want %v", got, want)
+	}
+}
+
+func TestCollectPlaceholders_FollowsTemplateCalls(t *testing.T) {
+	content := `{{define "unused"}}{{.secret}}{{end}}` +
+		`{{define "card"}}{{.name}} {{$.floor}}{{template "badge" .badge}}{{end}}` +
+		`{{define "badge"}}{{.color}}{{end}}` +
+		`{{define "loop"}}{{.depth}}{{template "loop" .child}}{{end}}` +
+		`{{template "card" .room}} {{template "loop" .}} {{template "badge"}}`
+	tmpl, err := readTemplate(strings.NewReader(content), parserConfig{})
+	if err != nil {
+		t.Fatalf("readTemplate() unexpected error: %v", err)
+	}
+
+	got := collectPlaceholders(tmpl)
+
+	want := [][]string{
+		{"child"},
+		{"depth"},
+		{"room"},
+		{"room", "badge"},
+		{"room", "badge", "color"},
+		{"room", "floor"},
+		{"room", "name"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectPlaceholders() = %v, want %v", got, want)
+	}
+}
+
+func TestFillMissing(t *testing.T) {
+	room := map[string]any{"name": "Blue"}
+	data := TemplateData{"name": "Alice", "room": room, "count": int64(2)}
+
+	got := fillMissing(data, [][]string{{"name"}, {"email"}, {"room", "floor"}, {"team", "name"}, {"count", "value"}}, "-")
+
+	want := TemplateData{
+		"name":  "Alice",
+		"email": "-",
+		"room":  map[string]any{"name": "Blue", "floor": "-"},
+		"team":  map[string]any{"name": "-"},
+		"count": int64(2),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fillMissing() = %v, want %v", got, want)
+	}
+	if _, ok := data["email"]; ok {
+		t.Error("fillMissing() modified the original data")
+	}
+	if _, ok := room["floor"]; ok {
+		t.Error("fillMissing() modified a nested map of the original data")
+	}
+}
@@ -23,6 +23,8 @@ func NewParserRegistry() *Registry {
 	registry.Register("yaml", &YAMLParser{})
 	registry.Register("yml", &YAMLParser{})
 	registry.Register("toml", &TOMLParser{})
+	registry.Register("csv", &CSVParser{})
+	registry.Register("tsv", NewTSVParser())
 
 	initializers.Logger.Info("Parser registry initialized", "supported_formats", registry.SupportedFormats())
 	return registry
@@ -44,7 +44,7 @@ func TestRegistry_ParseJSONFile(t *testing.T) {
 	}
 	defer closeFile(t, file)
 
-	messages, err := parser.Parse(file)
+	messages, err := parseRecipients(parser, file)
 	if err != nil {
 		t.Fatalf("Parser.Parse() unexpected error: %v", err)
 	}
@@ -91,7 +91,7 @@ channel2:
 	}
 	defer closeFile(t, file)
 
-	messages, err := parser.Parse(file)
+	messages, err := parseRecipients(parser, file)
 	if err != nil {
 		t.Fatalf("Parser.Parse() unexpected error: %v", err)
 	}
@@ -136,7 +136,7 @@ channel2:
 	}
 	defer closeFile(t, file)
 
-	messages, err := parser.Parse(file)
+	messages, err := parseRecipients(parser, file)
 	if err != nil {
 		t.Fatalf("Parser.Parse() unexpected error: %v", err)
 	}
@@ -180,7 +180,7 @@ email = "bob@example.com"
 	}
 	defer closeFile(t, file)
 
-	messages, err := parser.Parse(file)
+	messages, err := parseRecipients(parser, file)
 	if err != nil {
 		t.Fatalf("Parser.Parse() unexpected error: %v", err)
 	}
@@ -224,6 +224,8 @@ func TestRegistry_SupportedFormats(t *testing.T) {
 		"yaml": true,
 		"yml":  true,
 		"toml": true,
+		"csv":  true,
+		"tsv":  true,
 	}
 
 	if len(formats) != len(expectedFormats) {
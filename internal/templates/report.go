@@ -0,0 +1,83 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// execErrorRegex extracts the position and placeholder from text/template execution errors, e.g.
+// template: message:1:15: executing "message" at <.email>: map has no entry for key "email"
+var execErrorRegex = regexp.MustCompile(`^template: ([^:]*):(\d+):(\d+): executing "[^"]*" at <([^>]*)>: `)
+
+// RecipientError describes why the message for a single recipient could not be rendered
+type RecipientError struct {
+	// Recipient is the name of the recipient whose message failed
+	Recipient string
+	// Placeholder is the template action that failed, e.g. ".email", if known
+	Placeholder string
+	// Line and Column locate the failing action in the template, if known
+	Line, Column int
+	// Err is the underlying template execution error
+	Err error
+}
+
+// newRecipientError wraps an error rendering tmpl, locating the failing action
+// in the template file, front matter included
+func newRecipientError(recipient string, err error, tmpl *messageTemplate) *RecipientError {
+	re := &RecipientError{Recipient: recipient, Err: err}
+	if m := execErrorRegex.FindStringSubmatch(err.Error()); m != nil {
+		re.Line, _ = strconv.Atoi(m[2])
+		re.Line += tmpl.lineOffset(m[1])
+		re.Column, _ = strconv.Atoi(m[3])
+		re.Placeholder = m[4]
+	}
+	return re
+}
+
+// Error implements error
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("%s for recipient %q: %s", errTemplateRenderFailed, e.Recipient, e.Err)
+}
+
+// Unwrap allows matching both errTemplateRenderFailed and the underlying error
+func (e *RecipientError) Unwrap() []error {
+	return []error{errTemplateRenderFailed, e.Err}
+}
+
+// MissingKey reports whether the failure was caused by a placeholder absent from the data
+func (e *RecipientError) MissingKey() bool {
+	return strings.Contains(e.Err.Error(), "map has no entry for key")
+}
+
+// RenderReport collects the recipients whose messages could not be rendered.
+// It is returned as the error of TemplateParser.Parse alongside the successfully rendered messages.
+type RenderReport struct {
+	Failures []*RecipientError
+}
+
+// Error implements error, listing every failed recipient on its own line
+func (r *RenderReport) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s for %d recipient(s):", errTemplateRenderFailed, len(r.Failures))
+	for _, f := range r.Failures {
+		b.WriteString("\n  ")
+		b.WriteString(strconv.Quote(f.Recipient))
+		if f.Placeholder != "" {
+			fmt.Fprintf(&b, " at <%s> (line %d, column %d)", f.Placeholder, f.Line, f.Column)
+		}
+		b.WriteString(": ")
+		b.WriteString(f.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the individual recipient errors
+func (r *RenderReport) Unwrap() []error {
+	errs := make([]error, len(r.Failures))
+	for i, f := range r.Failures {
+		errs[i] = f
+	}
+	return errs
+}
@@ -0,0 +1,117 @@
+package templates
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// sanitizeTagRegex matches a single start, end or self-closing tag
+	sanitizeTagRegex = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[^<>]*?)?)\s*(/?)>`)
+	// sanitizeAttrRegex matches a single attribute of a tag
+	sanitizeAttrRegex = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+))`)
+	// sanitizeEntityRegex matches a character reference at the start of the input
+	sanitizeEntityRegex = regexp.MustCompile(`^&(?:[a-zA-Z][a-zA-Z0-9]{1,31}|#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6});`)
+)
+
+// allowedTags lists the HTML elements kept in messages, mapped to whether they are void elements
+var allowedTags = map[string]bool{
+	"a": false, "b": false, "strong": false, "i": false, "em": false, "u": false, "s": false,
+	"strike": false, "del": false, "p": false, "h1": false, "h2": false, "h3": false,
+	"ul": false, "ol": false, "li": false, "blockquote": false, "pre": false, "code": false,
+	"span": false, "br": true, "hr": true,
+}
+
+// sanitizeHTML keeps the allow-listed subset of HTML that Teams renders and escapes
+// everything else, so disallowed or malformed tags are shown as text. Only the href
+// attribute of links is kept, and only for http, https and mailto URLs.
+// An error is returned if allowed tags are not properly nested and closed.
+func sanitizeHTML(content string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(content))
+	var open []string
+
+	for i := 0; i < len(content); {
+		switch content[i] {
+		case '<':
+			m := sanitizeTagRegex.FindStringSubmatch(content[i:])
+			if m == nil || !isAllowedTag(m[2]) {
+				b.WriteString("&lt;")
+				i++
+				continue
+			}
+			name := strings.ToLower(m[2])
+			closing, void := m[1] == "/", allowedTags[name]
+
+			switch {
+			case void:
+				if !closing {
+					b.WriteString("<" + name + ">")
+				}
+			case closing:
+				if len(open) == 0 || open[len(open)-1] != name {
+					return "", unbalancedTagError(name, open)
+				}
+				open = open[:len(open)-1]
+				b.WriteString("</" + name + ">")
+			case m[4] == "/":
+				// self-closing non-void element, e.g. <b/>, renders nothing
+			default:
+				open = append(open, name)
+				b.WriteString("<" + name + sanitizeAttributes(name, m[3]) + ">")
+			}
+			i += len(m[0])
+		case '>':
+			b.WriteString("&gt;")
+			i++
+		case '&':
+			if entity := sanitizeEntityRegex.FindString(content[i:]); entity != "" {
+				b.WriteString(entity)
+				i += len(entity)
+				continue
+			}
+			b.WriteString("&amp;")
+			i++
+		default:
+			b.WriteByte(content[i])
+			i++
+		}
+	}
+
+	if len(open) > 0 {
+		return "", fmt.Errorf("%w: unclosed <%s>", errUnbalancedHTML, open[len(open)-1])
+	}
+	return b.String(), nil
+}
+
+func isAllowedTag(name string) bool {
+	_, ok := allowedTags[strings.ToLower(name)]
+	return ok
+}
+
+func unbalancedTagError(name string, open []string) error {
+	if len(open) == 0 {
+		return fmt.Errorf("%w: unexpected </%s>", errUnbalancedHTML, name)
+	}
+	return fmt.Errorf("%w: unexpected </%s>, expected </%s>", errUnbalancedHTML, name, open[len(open)-1])
+}
+
+// sanitizeAttributes returns the allowed attributes of a tag, ready to be written after its name
+func sanitizeAttributes(name, attrs string) string {
+	if name != "a" {
+		return ""
+	}
+	for _, m := range sanitizeAttrRegex.FindAllStringSubmatch(attrs, -1) {
+		if !strings.EqualFold(m[1], "href") {
+			continue
+		}
+		href := html.UnescapeString(m[2] + m[3] + m[4])
+		if !mdSafeSchemeRegex.MatchString(strings.TrimSpace(href)) {
+			return ""
+		}
+		return ` href="` + html.EscapeString(strings.TrimSpace(href)) + `"`
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "allowed tags kept",
+			content: "<p>Hello <b>Alice</b>,<br>\n<i>welcome</i><br/></p><ul><li>one</li></ul>",
+			want:    "<p>Hello <b>Alice</b>,<br>\n<i>welcome</i><br></p><ul><li>one</li></ul>",
+		},
+		{
+			name:    "disallowed tags escaped",
+			content: `<script>alert("x")</script><div>text</div>`,
+			want:    `&lt;script&gt;alert("x")&lt;/script&gt;&lt;div&gt;text&lt;/div&gt;`,
+		},
+		{
+			name:    "attributes stripped",
+			content: `<p class="x" onclick="evil()">hi</p>`,
+			want:    `<p>hi</p>`,
+		},
+		{
+			name:    "safe link kept",
+			content: `<a href='https://example.com/?a=1&amp;b=2' target="_blank">link</a>`,
+			want:    `<a href="https://example.com/?a=1&amp;b=2">link</a>`,
+		},
+		{
+			name:    "unsafe link href dropped",
+			content: `<a href="javascript:alert(1)">link</a>`,
+			want:    `<a>link</a>`,
+		},
+		{
+			name:    "stray brackets and ampersands escaped",
+			content: "1 < 2 & 3 > 2 &amp; &#169; &copy; &bogus",
+			want:    "1 &lt; 2 &amp; 3 &gt; 2 &amp; &#169; &copy; &amp;bogus",
+		},
+		{
+			name:    "tag names normalized",
+			content: "<B>bold</b>",
+			want:    "<b>bold</b>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sanitizeHTML(tt.content)
+			if err != nil {
+				t.Fatalf("sanitizeHTML() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("sanitizeHTML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHTML_Unbalanced(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "<b>bold", want: "unclosed <b>"},
+		{content: "<b><i>x</b></i>", want: "unexpected </b>, expected </i>"},
+		{content: "text</p>", want: "unexpected </p>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			_, err := sanitizeHTML(tt.content)
+			if !errors.Is(err, errUnbalancedHTML) {
+				t.Fatalf("sanitizeHTML() error = %v, want %v", err, errUnbalancedHTML)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("sanitizeHTML() error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_EscapesDataByDefault(t *testing.T) {
+	template := "<p>Hello {{.name}}!</p>"
+	data := `{"alice": {"name": "<script>x</script> & <b>co</b>"}}`
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "escaped",
+			want: "<p>Hello &lt;script&gt;x&lt;/script&gt; &amp; &lt;b&gt;co&lt;/b&gt;!</p>",
+		},
+		{
+			name: "raw data still sanitized",
+			opts: []Option{WithRawData()},
+			want: "<p>Hello &lt;script&gt;x&lt;/script&gt; &amp; <b>co</b>!</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+			if messages[0].Body != tt.want {
+				t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageParser_EscapesValuesAfterFunctions(t *testing.T) {
+	template := `<p>{{if eq .dept "R&D"}}{{truncate 6 .dept}}{{end}} {{len .dept}} {{.dept | upper}} ` +
+		`{{range .items}}{{.name}} {{end}}{{safe .sig}}</p>`
+	data := `
+[alice]
+dept = "R&D"
+sig = "<i>A</i>"
+
+[[alice.items]]
+name = "<b>x</b>"
+`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &TOMLParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := mp.Parse()
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	want := "<p>R&amp;D 3 R&amp;D &lt;b&gt;x&lt;/b&gt; <i>A</i></p>"
+	if messages[0].Body != want {
+		t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, want)
+	}
+}
+
+func TestMessageParser_TruncateDoesNotCutEntities(t *testing.T) {
+	mp, err := NewMessageParser(strings.NewReader("<p>{{truncate 4 .name}}</p>"), strings.NewReader(`{"alice": {"name": "A & B & C"}}`), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := mp.Parse()
+	if err != nil {
+		t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+	}
+
+	if want := "<p>A &amp;…</p>"; messages[0].Body != want {
+		t.Errorf("MessageParser.Parse() got %q, want %q", messages[0].Body, want)
+	}
+}
+
+func TestMessageParser_UnbalancedHTMLFailsRecipient(t *testing.T) {
+	template := "<p>Hello {{.name}}!{{if .vip}}</p>{{end}}"
+	data := `{"alice": {"name": "Alice", "vip": true}, "bob": {"name": "Bob", "vip": false}}`
+
+	mp, err := NewMessageParser(strings.NewReader(template), strings.NewReader(data), &JSONParser{}, WithCollectErrors())
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	messages, err := mp.Parse()
+
+	var report *RenderReport
+	if !errors.As(err, &report) || len(report.Failures) != 1 || report.Failures[0].Recipient != "bob" {
+		t.Fatalf("MessageParser.Parse() error = %v, want a report for bob", err)
+	}
+	if !errors.Is(err, errUnbalancedHTML) {
+		t.Errorf("MessageParser.Parse() error = %v, want wrapped %v", err, errUnbalancedHTML)
+	}
+	if len(messages) != 1 || messages[0].Recipient != "alice" {
+		t.Errorf("MessageParser.Parse() messages = %v, want alice only", messages)
+	}
+}
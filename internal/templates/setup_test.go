@@ -17,3 +17,29 @@ func TestMain(m *testing.M) {
 	})
 	os.Exit(m.Run())
 }
+
+// parseByRecipient renders mp and indexes the messages by recipient name
+func parseByRecipient(mp *TemplateParser) (map[string]string, error) {
+	messages, err := mp.Parse()
+	if messages == nil {
+		return nil, err
+	}
+	byRecipient := make(map[string]string, len(messages))
+	for _, message := range messages {
+		byRecipient[message.Recipient] = message.Body
+	}
+	return byRecipient, err
+}
+
+// parseRecipients parses r with parser and indexes the data by recipient name
+func parseRecipients(parser Parser, r io.Reader) (map[string]TemplateData, error) {
+	recipients, err := parser.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]TemplateData, len(recipients))
+	for _, recipient := range recipients {
+		byName[recipient.Name] = recipient.Data
+	}
+	return byName, nil
+}
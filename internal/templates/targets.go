@@ -0,0 +1,67 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pzsp-teams/cli/internal/recipients"
+)
+
+// TargetKey is the reserved recipient data entry addressing the recipient's message.
+// Its value is a target shorthand, such as "channel:Team/General", "chat:<id>" or
+// "user:alice@example.com", or a map with kind, team, channel, chat and user fields.
+// Being data, it can be shared or partly given in the DefaultsKey entry.
+const TargetKey = "_target"
+
+// resolveTargets sets the target of every recipient and removes TargetKey from its data.
+// Recipients without TargetKey are addressed by their name if it is a target shorthand.
+// Addresses without a kind, in TargetKey or the name, take the kind from defaultTarget
+// in the template front matter: for channels the address is "team/channel", for chats
+// it is a chat ID, or an e-mail address for the 1:1 chat with a user.
+// Recipients that cannot be addressed this way are left without a target.
+func resolveTargets(list []Recipient, defaultTarget string) error {
+	for i := range list {
+		target, err := recipientTarget(list[i], defaultTarget)
+		if err != nil {
+			return fmt.Errorf("%w for recipient %q: %w", errInvalidTarget, list[i].Name, err)
+		}
+		list[i].Target = target
+		delete(list[i].Data, TargetKey)
+	}
+	return nil
+}
+
+func recipientTarget(recipient Recipient, defaultTarget string) (*recipients.Target, error) {
+	value, hasValue := recipient.Data[TargetKey]
+	address, isAddress := value.(string)
+	if !hasValue {
+		address, isAddress = recipient.Name, true
+	}
+
+	var target recipients.Target
+	var err error
+	switch {
+	case !isAddress:
+		target, err = recipients.FromValue(value)
+	case recipients.HasKindPrefix(address) || (hasValue && defaultTarget == ""):
+		target, err = recipients.ParseTarget(address)
+	case defaultTarget == TargetChannel:
+		target, err = recipients.NewTarget(recipients.KindChannel, address)
+	case defaultTarget == TargetChat:
+		target, err = recipients.NewTarget(chatKind(address), address)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// chatKind tells user addresses from chat IDs, which always contain a colon
+func chatKind(address string) recipients.Kind {
+	if strings.Contains(address, "@") && !strings.Contains(address, ":") {
+		return recipients.KindUser
+	}
+	return recipients.KindChat
+}
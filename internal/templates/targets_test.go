@@ -0,0 +1,133 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pzsp-teams/cli/internal/recipients"
+)
+
+func TestMessageParser_Targets(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		data       string
+		parser     Parser
+		wantTarget map[string]string
+	}{
+		{
+			name:     "target entries",
+			template: "Hi {{.name}}",
+			data: `{
+				"alice": {"name": "Alice", "_target": "user:alice@example.com"},
+				"general": {"name": "all", "_target": {"team": "Engineering", "channel": "General"}},
+				"nobody": {"name": "Nobody"}
+			}`,
+			parser:     &JSONParser{},
+			wantTarget: map[string]string{"alice": "user:alice@example.com", "general": "channel:Engineering/General", "nobody": ""},
+		},
+		{
+			name:     "shared team in defaults",
+			template: "Hi {{.name}}",
+			data: "_defaults:\n  _target:\n    kind: channel\n    team: Engineering\n" +
+				"general:\n  name: all\n  _target:\n    channel: General\n" +
+				"random:\n  name: all\n  _target:\n    channel: Random\n",
+			parser:     &YAMLParser{},
+			wantTarget: map[string]string{"general": "channel:Engineering/General", "random": "channel:Engineering/Random"},
+		},
+		{
+			name:       "shorthand keys",
+			template:   "Hi {{.name}}",
+			data:       `{"user:bob@example.com": {"name": "Bob"}, "chat:19:abc@thread.v2": {"name": "all"}}`,
+			parser:     &JSONParser{},
+			wantTarget: map[string]string{"user:bob@example.com": "user:bob@example.com", "chat:19:abc@thread.v2": "chat:19:abc@thread.v2"},
+		},
+		{
+			name:       "front matter channel target",
+			template:   "---\ntarget: channel\n---\nHi {{.name}}",
+			data:       "_target,name\nEngineering/General,all\nSales/Leads,\"channel:Sales/Leads\"\n",
+			parser:     &CSVParser{KeyColumn: "name"},
+			wantTarget: map[string]string{"all": "channel:Engineering/General", "channel:Sales/Leads": "channel:Sales/Leads"},
+		},
+		{
+			name:       "front matter chat target",
+			template:   "---\ntarget: chat\n---\nHi",
+			data:       `{"bob@example.com": {}, "19:abc@thread.v2": {}}`,
+			parser:     &JSONParser{},
+			wantTarget: map[string]string{"bob@example.com": "user:bob@example.com", "19:abc@thread.v2": "chat:19:abc@thread.v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp, err := NewMessageParser(strings.NewReader(tt.template), strings.NewReader(tt.data), tt.parser)
+			if err != nil {
+				t.Fatalf("NewMessageParser() unexpected error: %v", err)
+			}
+			messages, err := mp.Parse()
+			if err != nil {
+				t.Fatalf("MessageParser.Parse() unexpected error: %v", err)
+			}
+
+			got := make(map[string]string, len(messages))
+			for _, message := range messages {
+				got[message.Recipient] = targetString(message.Target)
+			}
+			for recipient, want := range tt.wantTarget {
+				if got[recipient] != want {
+					t.Errorf("message for %q has target %q, want %q", recipient, got[recipient], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageParser_TargetNotInData(t *testing.T) {
+	data := `{"alice": {"name": "Alice", "_target": "user:alice@example.com"}}`
+
+	report, err := Lint(strings.NewReader("Hi {{.name}}"), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("Lint() unexpected error: %v", err)
+	}
+	if len(report.Unused) != 0 {
+		t.Errorf("Lint() Unused = %v, want the target entry not reported", report.Unused)
+	}
+
+	mp, err := NewMessageParser(strings.NewReader("{{._target}}"), strings.NewReader(data), &JSONParser{})
+	if err != nil {
+		t.Fatalf("NewMessageParser() unexpected error: %v", err)
+	}
+	var recipientErr *RecipientError
+	if _, err := mp.Parse(); !errors.As(err, &recipientErr) || !recipientErr.MissingKey() {
+		t.Errorf("MessageParser.Parse() error = %v, want the target entry missing from data", err)
+	}
+}
+
+func TestMessageParser_InvalidTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		data     string
+	}{
+		{"bad shorthand", "Hi", `{"alice": {"_target": "user:alice"}}`},
+		{"missing channel", "Hi", `{"general": {"_target": {"kind": "channel", "team": "Engineering"}}}`},
+		{"front matter channel without team", "---\ntarget: channel\n---\nHi", `{"General": {}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMessageParser(strings.NewReader(tt.template), strings.NewReader(tt.data), &JSONParser{})
+			if !errors.Is(err, errInvalidTarget) {
+				t.Errorf("NewMessageParser() error = %v, want %v", err, errInvalidTarget)
+			}
+		})
+	}
+}
+
+func targetString(target *recipients.Target) string {
+	if target == nil {
+		return ""
+	}
+	return target.String()
+}
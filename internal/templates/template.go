@@ -2,28 +2,293 @@ package templates
 
 import (
 	"fmt"
+	"html"
+	htmltemplate "html/template"
 	"io"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/pzsp-teams/cli/internal/initializers"
 )
 
-// readTemplate reads template content from r and returns a parsed text/template.
+// Names of the templates rendering the plain text parts of a message
+const (
+	subjectTemplate = "subject"
+	summaryTemplate = "summary"
+)
+
+// escapeFuncName is the function appended to printing actions of text templates
+// whose output is HTML-escaped
+const escapeFuncName = "_escapeHTML"
+
+// safeHTML is a value marked by the safe function as trusted markup
+type safeHTML string
+
+// messageTemplate is a parsed message template, executed with text/template
+// or, in HTML-aware mode, with html/template
+type messageTemplate struct {
+	text *template.Template
+	html *htmltemplate.Template
+	// entry is the name of the template executed to render a message
+	entry string
+	// body is the name of the template parsed from the message template file
+	body string
+	// offset is the number of front matter lines preceding the body
+	offset int
+	// metadata is read from the template front matter
+	metadata Metadata
+	// config is the parser configuration with front matter settings applied
+	config parserConfig
+}
+
+// Execute applies the entry template to data, writing the output to w
+func (t *messageTemplate) Execute(w io.Writer, data any) error {
+	return t.ExecuteTemplate(w, t.entry, data)
+}
+
+// ExecuteTemplate applies the template with the given name to data, writing the output to w
+func (t *messageTemplate) ExecuteTemplate(w io.Writer, name string, data any) error {
+	if t.html != nil {
+		return t.html.ExecuteTemplate(w, name, data)
+	}
+	return t.text.ExecuteTemplate(w, name, data)
+}
+
+// lineOffset returns the number of lines to add to a line number reported for
+// the named template to get the line in its file
+func (t *messageTemplate) lineOffset(name string) int {
+	if name == t.body {
+		return t.offset
+	}
+	return 0
+}
+
+// defines reports whether a template with the given name is defined
+func (t *messageTemplate) defines(name string) bool {
+	if t.html != nil {
+		return t.html.Lookup(name) != nil
+	}
+	return t.text.Lookup(name) != nil
+}
+
+// addPart parses text as the template with the given name, unless it is empty
+// or a template with that name is already defined
+func (t *messageTemplate) addPart(name, text string) error {
+	if text == "" || t.defines(name) {
+		return nil
+	}
+	var err error
+	if t.html != nil {
+		_, err = t.html.New(name).Parse(text)
+	} else {
+		_, err = t.text.New(name).Parse(text)
+	}
+	return err
+}
+
+// tree returns the parse tree of the template with the given name, or nil if it is not defined
+func (t *messageTemplate) tree(name string) *parse.Tree {
+	if t.html != nil {
+		if tmpl := t.html.Lookup(name); tmpl != nil {
+			return tmpl.Tree
+		}
+		return nil
+	}
+	if tmpl := t.text.Lookup(name); tmpl != nil {
+		return tmpl.Tree
+	}
+	return nil
+}
+
+// templateSyntaxError is a syntax error in one template source
+type templateSyntaxError struct {
+	// file is the partial that failed to parse, or empty for the message template
+	file    string
+	content string
+	// offset is the number of front matter lines preceding content
+	offset int
+	err    error
+}
+
+// Error implements error, prefixing the message with the failing file, if any
+func (e *templateSyntaxError) Error() string {
+	if e.file != "" {
+		return e.file + ": " + e.err.Error()
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying parse error
+func (e *templateSyntaxError) Unwrap() error {
+	return e.err
+}
+
+// readTemplate reads template content from r and returns a parsed template.
 // The template uses Go's text/template syntax with {{.placeholder}} format.
 // Returns an error if reading fails or if the template syntax is invalid.
-// Templates are configured to return an error if any placeholder is missing from the data.
-func readTemplate(r io.Reader) (*template.Template, error) {
+// Templates are configured to return an error if any placeholder is missing from the data
+// and have the functions from templateFuncs available. With WithHTMLEscaping the
+// template is parsed by html/template, which escapes values according to their context.
+// Partials are parsed before the message template, so the message can redefine their blocks.
+// Front matter is removed before parsing and its settings are applied to cfg.
+// The subject and summary from front matter become the "subject" and "summary"
+// templates, unless the message template or a partial defines them.
+func readTemplate(r io.Reader, cfg parserConfig) (*messageTemplate, error) {
 	content, err := io.ReadAll(r)
 	if err != nil {
 		initializers.Logger.Error(errTemplateReadFailed.Error(), "error", err)
 		return nil, fmt.Errorf("%w: %w", errTemplateReadFailed, err)
 	}
 
-	tmpl, err := template.New("message").Option("missingkey=error").Parse(string(content))
+	meta, body, err := splitFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+	if cfg, err = cfg.withMetadata(meta); err != nil {
+		initializers.Logger.Error(errIncompatibleOptions.Error(), "error", err)
+		return nil, err
+	}
+
+	var partials []partial
+	if cfg.partialsDir != "" {
+		if partials, err = loadPartials(cfg.partialsDir); err != nil {
+			return nil, err
+		}
+	}
+
+	name, entry := "message", "message"
+	if cfg.layout != "" {
+		name, entry = "content", cfg.layout
+	}
+
+	var parsed *messageTemplate
+	if cfg.htmlEscaping {
+		parsed, err = parseHTMLTemplate(name, body, partials)
+	} else {
+		parsed, err = parseTextTemplate(name, body, partials)
+	}
 	if err != nil {
 		initializers.Logger.Error(errTemplateParseFailed.Error(), "error", err)
 		return nil, fmt.Errorf("%w: %w", errTemplateParseFailed, err)
 	}
 
-	return tmpl, nil
+	parsed.entry, parsed.metadata, parsed.config = entry, meta, cfg
+	parsed.body, parsed.offset = name, body.offset
+	parts := []struct{ name, text string }{{subjectTemplate, meta.Subject}, {summaryTemplate, meta.Summary}}
+	for _, part := range parts {
+		if err := parsed.addPart(part.name, part.text); err != nil {
+			initializers.Logger.Error(errFrontMatterInvalid.Error(), "error", err)
+			return nil, fmt.Errorf("%w: %w", errFrontMatterInvalid, err)
+		}
+	}
+	if !parsed.defines(entry) {
+		initializers.Logger.Error(errLayoutNotFound.Error(), "layout", entry)
+		return nil, fmt.Errorf("%w: %q", errLayoutNotFound, entry)
+	}
+	if cfg.escapesOutput() {
+		parsed.escapeOutput()
+	}
+	return parsed, nil
+}
+
+func parseTextTemplate(name string, body frontMatterBody, partials []partial) (*messageTemplate, error) {
+	root := template.New(name).Funcs(textTemplateFuncs()).Option("missingkey=error")
+	for _, p := range partials {
+		if _, err := root.New(p.name).Parse(p.content); err != nil {
+			return nil, &templateSyntaxError{file: p.file, content: p.content, err: err}
+		}
+	}
+	if _, err := root.Parse(body.content); err != nil {
+		return nil, &templateSyntaxError{content: body.content, offset: body.offset, err: err}
+	}
+	return &messageTemplate{text: root}, nil
+}
+
+func parseHTMLTemplate(name string, body frontMatterBody, partials []partial) (*messageTemplate, error) {
+	root := htmltemplate.New(name).Funcs(htmlTemplateFuncs()).Option("missingkey=error")
+	for _, p := range partials {
+		if _, err := root.New(p.name).Parse(p.content); err != nil {
+			return nil, &templateSyntaxError{file: p.file, content: p.content, err: err}
+		}
+	}
+	if _, err := root.Parse(body.content); err != nil {
+		return nil, &templateSyntaxError{content: body.content, offset: body.offset, err: err}
+	}
+	return &messageTemplate{html: root}, nil
+}
+
+// textTemplateFuncs returns templateFuncs with the safe escape hatch for text/template
+// and the escaper that escapeOutput adds to printing actions
+func textTemplateFuncs() template.FuncMap {
+	funcs := templateFuncs()
+	funcs["safe"] = func(value any) safeHTML {
+		return safeHTML(fmt.Sprint(value))
+	}
+	funcs[escapeFuncName] = escapeHTML
+	return funcs
+}
+
+// escapeHTML HTML-escapes a printed value, writing values marked with safe as they are
+func escapeHTML(value any) string {
+	switch v := value.(type) {
+	case safeHTML:
+		return string(v)
+	case nil:
+		// text/template prints a nil value this way
+		return html.EscapeString("<no value>")
+	default:
+		return html.EscapeString(fmt.Sprint(v))
+	}
+}
+
+// escapeOutput makes the text template HTML-escape every value it prints, by
+// appending escapeHTML to the pipelines of printing actions as html/template does.
+// Values are escaped only as they are written, so functions and comparisons see the data as is.
+func (t *messageTemplate) escapeOutput() {
+	for _, tmpl := range t.text.Templates() {
+		if tmpl.Tree != nil {
+			escapeActions(tmpl.Root)
+		}
+	}
+}
+
+// escapeActions appends the escaper to the printing actions under node
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		// actions declaring or assigning variables print nothing
+		if len(n.Pipe.Decl) == 0 {
+			escaper := parse.NewIdentifier(escapeFuncName).SetTree(nil).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{escaper}})
+		}
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
+
+// htmlTemplateFuncs returns templateFuncs with escape hatches marking trusted values:
+// safe for HTML fragments and safeURL for link targets.
+func htmlTemplateFuncs() htmltemplate.FuncMap {
+	funcs := htmltemplate.FuncMap(templateFuncs())
+	funcs["safe"] = func(value any) htmltemplate.HTML {
+		return htmltemplate.HTML(fmt.Sprint(value))
+	}
+	funcs["safeURL"] = func(value any) htmltemplate.URL {
+		return htmltemplate.URL(fmt.Sprint(value))
+	}
+	return funcs
 }
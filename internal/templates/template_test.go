@@ -9,7 +9,7 @@ func TestReadTemplate_ValidTemplate(t *testing.T) {
 	template := "Hello {{.name}}! Welcome to {{.place}}."
 	reader := strings.NewReader(template)
 
-	tmpl, err := readTemplate(reader)
+	tmpl, err := readTemplate(reader, parserConfig{})
 	if err != nil {
 		t.Fatalf("ReadTemplate() unexpected error: %v", err)
 	}
@@ -23,7 +23,7 @@ func TestReadTemplate_InvalidSyntax(t *testing.T) {
 	template := "Hello {{.name}! Missing closing braces"
 	reader := strings.NewReader(template)
 
-	_, err := readTemplate(reader)
+	_, err := readTemplate(reader, parserConfig{})
 	if err == nil {
 		t.Error("ReadTemplate() expected error for invalid template syntax, got nil")
 	}
@@ -33,7 +33,7 @@ func TestReadTemplate_UnclosedAction(t *testing.T) {
 	template := "Hello {{.name"
 	reader := strings.NewReader(template)
 
-	_, err := readTemplate(reader)
+	_, err := readTemplate(reader, parserConfig{})
 	if err == nil {
 		t.Error("ReadTemplate() expected error for unclosed action, got nil")
 	}
@@ -43,7 +43,7 @@ func TestReadTemplate_InvalidAction(t *testing.T) {
 	template := "Hello {{if .name}}"
 	reader := strings.NewReader(template)
 
-	_, err := readTemplate(reader)
+	_, err := readTemplate(reader, parserConfig{})
 	if err == nil {
 		t.Error("ReadTemplate() expected error for unclosed if statement, got nil")
 	}
@@ -53,7 +53,7 @@ func TestReadTemplate_EmptyTemplate(t *testing.T) {
 	template := ""
 	reader := strings.NewReader(template)
 
-	tmpl, err := readTemplate(reader)
+	tmpl, err := readTemplate(reader, parserConfig{})
 	if err != nil {
 		t.Fatalf("ReadTemplate() unexpected error for empty template: %v", err)
 	}
@@ -73,7 +73,7 @@ Time: {{.time}}
 Regards`
 	reader := strings.NewReader(template)
 
-	tmpl, err := readTemplate(reader)
+	tmpl, err := readTemplate(reader, parserConfig{})
 	if err != nil {
 		t.Fatalf("ReadTemplate() unexpected error: %v", err)
 	}
@@ -0,0 +1,113 @@
+package templates
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// textTagRegex matches a tag of the sanitized message HTML, capturing the href of links
+	textTagRegex = regexp.MustCompile(`<(/?)([a-z0-9]+)(?:\s+href="([^"]*)")?[^>]*>`)
+	// blankLinesRegex matches runs of blank lines
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts message HTML, as in Message.Body, into plain text for terminals.
+// Block elements are separated by blank lines, list items are prefixed with a bullet
+// or their number, and link targets follow the link text in parentheses.
+func HTMLToText(body string) string {
+	w := &textWriter{}
+	last := 0
+	for _, m := range textTagRegex.FindAllStringSubmatchIndex(body, -1) {
+		w.b.WriteString(html.UnescapeString(body[last:m[0]]))
+		last = m[1]
+		href := ""
+		if m[6] >= 0 {
+			href = html.UnescapeString(body[m[6]:m[7]])
+		}
+		w.tag(body[m[4]:m[5]], body[m[2]:m[3]] == "/", href)
+	}
+	w.b.WriteString(html.UnescapeString(body[last:]))
+
+	text := blankLinesRegex.ReplaceAllString(w.b.String(), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// textWriter accumulates the plain text of message HTML
+type textWriter struct {
+	b strings.Builder
+	// lists holds the item counters of the open lists, -1 for bulleted lists
+	lists []int
+	// href is the target of the open link and linkStart the offset of its text
+	href      string
+	linkStart int
+}
+
+// tag writes the text standing for a start or end tag
+func (w *textWriter) tag(name string, closing bool, href string) {
+	switch name {
+	case "br":
+		w.b.WriteString("\n")
+	case "hr":
+		w.b.WriteString("\n\n----\n\n")
+	case "p", "h1", "h2", "h3", "blockquote", "pre":
+		w.b.WriteString("\n\n")
+	case "ul", "ol":
+		w.list(name == "ol", closing)
+	case "li":
+		if !closing {
+			w.b.WriteString("\n" + listMarker(w.lists))
+		}
+	case "a":
+		w.link(closing, href)
+	}
+}
+
+// list opens or closes a bulleted or numbered list
+func (w *textWriter) list(ordered, closing bool) {
+	switch {
+	case closing:
+		w.lists = w.lists[:max(len(w.lists)-1, 0)]
+	case ordered:
+		w.lists = append(w.lists, 0)
+	default:
+		w.lists = append(w.lists, -1)
+	}
+	if len(w.lists) == 0 || (!closing && len(w.lists) == 1) {
+		// nested lists continue the item they are in
+		w.b.WriteString("\n")
+	}
+}
+
+// link starts a link to href, or ends the open one by writing its target
+func (w *textWriter) link(closing bool, href string) {
+	if !closing {
+		w.href, w.linkStart = href, w.b.Len()
+		return
+	}
+	if w.href == "" {
+		return
+	}
+	// links whose text is the target itself are shown once
+	if w.b.String()[w.linkStart:] != w.href {
+		w.b.WriteString(" (" + w.href + ")")
+	}
+	w.href = ""
+}
+
+// listMarker returns the prefix of the next item of the innermost open list,
+// indented by its nesting depth
+func listMarker(lists []int) string {
+	if len(lists) == 0 {
+		return "- "
+	}
+	indent := strings.Repeat("  ", len(lists)-1)
+	innermost := len(lists) - 1
+	if lists[innermost] < 0 {
+		return indent + "- "
+	}
+	lists[innermost]++
+	return indent + strconv.Itoa(lists[innermost]) + ". "
+}
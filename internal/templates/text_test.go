@@ -0,0 +1,33 @@
+package templates
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"plain", "Hello Alice &amp; Bob", "Hello Alice & Bob"},
+		{"line breaks", "Line 1<br>Line 2", "Line 1\nLine 2"},
+		{"paragraphs", "<h1>Title</h1><p>First</p><p>Second</p>", "Title\n\nFirst\n\nSecond"},
+		{"formatting", "<p><b>Bold</b> and <i>italic</i> <code>x &lt; 1</code></p>", "Bold and italic x < 1"},
+		{"bulleted list", "<p>Items:</p><ul><li>One</li><li>Two</li></ul>", "Items:\n\n- One\n- Two"},
+		{
+			name: "nested numbered list",
+			body: "<ol><li>First<ul><li>Sub</li></ul></li><li>Second</li></ol>",
+			want: "1. First\n  - Sub\n2. Second",
+		},
+		{"link", `See <a href="https://example.com/?a=1&amp;b=2">the docs</a>.`, "See the docs (https://example.com/?a=1&b=2)."},
+		{"self link", `<a href="https://example.com">https://example.com</a>`, "https://example.com"},
+		{"rule", "Above<hr>Below", "Above\n\n----\n\nBelow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTMLToText(tt.body); got != tt.want {
+				t.Errorf("HTMLToText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
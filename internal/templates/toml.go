@@ -12,11 +12,22 @@ import (
 type TOMLParser struct{}
 
 // Parse reads TOML-formatted message data
-func (p *TOMLParser) Parse(r io.Reader) (map[string]TemplateData, error) {
+func (p *TOMLParser) Parse(r io.Reader) ([]Recipient, error) {
 	var messages map[string]TemplateData
-	if _, err := toml.NewDecoder(r).Decode(&messages); err != nil {
+	metadata, err := toml.NewDecoder(r).Decode(&messages)
+	if err != nil {
 		initializers.Logger.Error(errTOMLDecodeFailed.Error(), "error", err)
 		return nil, fmt.Errorf("%w: %w", errTOMLDecodeFailed, err)
 	}
-	return messages, nil
+	return applyDefaults(orderRecipients(messages, tomlKeyOrder(metadata))), nil
+}
+
+// tomlKeyOrder returns top-level keys in the order they first appear in the document.
+// Repeated keys are removed by orderRecipients.
+func tomlKeyOrder(metadata toml.MetaData) []string {
+	keys := make([]string, 0, len(metadata.Keys()))
+	for _, key := range metadata.Keys() {
+		keys = append(keys, key[0])
+	}
+	return keys
 }
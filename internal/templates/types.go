@@ -1,12 +1,40 @@
 package templates
 
-import "io"
+import (
+	"io"
 
-// TemplateData represents placeholder values for a single message recipient
-type TemplateData map[string]string
+	"github.com/pzsp-teams/cli/internal/recipients"
+)
+
+// TemplateData represents placeholder values for a single message recipient.
+// Values may be strings, numbers, booleans, lists or nested maps, so templates
+// can use {{range}}, {{if}} and {{.nested.field}} on structured data.
+type TemplateData map[string]any
+
+// Recipient holds the placeholder values for a single named message recipient
+type Recipient struct {
+	Name string
+	Data TemplateData
+	// Target is where the recipient's message is delivered, or nil if the data does not say
+	Target *recipients.Target
+}
+
+// Message is the rendered message for a single recipient
+type Message struct {
+	Recipient string
+	// Target is where the message is delivered, or nil if the data does not say
+	Target *recipients.Target
+	// Subject is the plain text subject line, or empty if the template defines none
+	Subject string
+	// Body is the message content in the HTML subset Teams renders
+	Body string
+	// Summary is the plain text preview shown in notifications, or empty if the template defines none
+	Summary string
+}
 
 // Parser defines the interface for parsing message data from different formats
 type Parser interface {
-	// Parse reads and parses message data from r
-	Parse(r io.Reader) (map[string]TemplateData, error)
+	// Parse reads and parses message data from r.
+	// Recipients are returned in the order they appear in the data.
+	Parse(r io.Reader) ([]Recipient, error)
 }
@@ -8,16 +8,128 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// yamlMergeKey is the key YAML uses to merge another mapping into the current one
+const yamlMergeKey = "<<"
+
 // YAMLParser implements Parser for YAML format
 type YAMLParser struct{}
 
 // Parse reads YAML-formatted message data
-func (p *YAMLParser) Parse(r io.Reader) (map[string]TemplateData, error) {
+func (p *YAMLParser) Parse(r io.Reader) ([]Recipient, error) {
+	var document yaml.Node
 	var messages map[string]TemplateData
 	decoder := yaml.NewDecoder(r)
-	if err := decoder.Decode(&messages); err != nil {
+	if err := decoder.Decode(&document); err != nil {
+		initializers.Logger.Error(errYAMLDecodeFailed.Error(), "error", err)
+		return nil, fmt.Errorf("%w: %w", errYAMLDecodeFailed, err)
+	}
+	if err := document.Decode(&messages); err != nil {
 		initializers.Logger.Error(errYAMLDecodeFailed.Error(), "error", err)
 		return nil, fmt.Errorf("%w: %w", errYAMLDecodeFailed, err)
 	}
-	return messages, nil
+	if root, ok := yamlValue(&document).(map[string]any); ok {
+		for name := range messages {
+			if data, ok := root[name].(map[string]any); ok {
+				messages[name] = data
+			}
+		}
+	}
+	return applyDefaults(normalizeData(orderRecipients(messages, yamlKeyOrder(&document)))), nil
+}
+
+// yamlKeyOrder returns the keys of the top-level mapping in document order
+func yamlKeyOrder(document *yaml.Node) []string {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	keys := make([]string, 0, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keys = append(keys, root.Content[i].Value)
+	}
+	return keys
+}
+
+// yamlValue converts a node into plain Go values, keeping the source text of
+// scalars whose typed form would print differently (01234, 10.50, 1.10)
+func yamlValue(node *yaml.Node) any {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		return yamlMapping(node)
+	case yaml.SequenceNode:
+		items := make([]any, len(node.Content))
+		for i, item := range node.Content {
+			items[i] = yamlValue(item)
+		}
+		return items
+	default:
+		return yamlScalar(node)
+	}
+}
+
+// yamlMapping converts a mapping node, applying merge keys before the
+// mapping's own entries so that explicit keys win
+func yamlMapping(node *yaml.Node) map[string]any {
+	result := make(map[string]any, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == yamlMergeKey && node.Content[i].Tag == "!!merge" {
+			yamlMerge(result, node.Content[i+1])
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Value == yamlMergeKey && key.Tag == "!!merge" {
+			continue
+		}
+		result[key.Value] = yamlValue(node.Content[i+1])
+	}
+	return result
+}
+
+// yamlMerge copies the entries of a merged mapping (or list of mappings)
+// into result without overwriting keys that are already set
+func yamlMerge(result map[string]any, node *yaml.Node) {
+	sources := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+	for _, source := range sources {
+		merged, ok := yamlValue(source).(map[string]any)
+		if !ok {
+			continue
+		}
+		for key, value := range merged {
+			if _, exists := result[key]; !exists {
+				result[key] = value
+			}
+		}
+	}
+}
+
+// yamlScalar decodes a scalar, falling back to its source text when the
+// decoded value does not print back the same way
+func yamlScalar(node *yaml.Node) any {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return node.Value
+	}
+	switch value.(type) {
+	case nil, string:
+		return value
+	}
+	if fmt.Sprint(value) != node.Value {
+		return node.Value
+	}
+	return value
 }
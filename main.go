@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pzsp-teams/cli/internal/cli"
+	"github.com/pzsp-teams/cli/internal/initializers"
+	"github.com/pzsp-teams/cli/internal/logger"
+
+	// time zone data for --tz on systems without a zone database, such as Windows
+	_ "time/tzdata"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	args, verbose := extractVerbose(os.Args[1:])
+
+	logFile := openLogFile()
+	if logFile != nil {
+		defer func() {
+			if err := logFile.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to close log file: %v\n", err)
+			}
+		}()
+	}
+	initLogger(logFile, verbose)
+
+	return cli.New().Run(args)
+}
+
+// extractVerbose removes the global -v/--verbose flag given before the command from args.
+// Arguments from the command name on are left alone, so "send --data -v" keeps its value.
+func extractVerbose(args []string) ([]string, bool) {
+	verbose := false
+	for len(args) > 0 && (args[0] == "-v" || args[0] == "--verbose") {
+		verbose = true
+		args = args[1:]
+	}
+	return args, verbose
+}
+
+// openLogFile opens the log file in the user cache directory, returning nil if that is not possible
+func openLogFile() *os.File {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	dir := filepath.Join(cacheDir, "pzsp-teams")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil
+	}
+	logFile, err := os.OpenFile(filepath.Join(dir, "cli.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open log file: %v\n", err)
+		return nil
+	}
+	return logFile
+}
+
+func initLogger(logFile *os.File, verbose bool) {
+	stderrLevel := logger.LevelError
+	if verbose {
+		stderrLevel = logger.LevelDebug
+	}
+
+	var fileWriter io.Writer = io.Discard
+	if logFile != nil {
+		fileWriter = logFile
+	}
+
+	initializers.InitMultiOutputLogger(initializers.MultiOutputConfig{
+		StderrLevel:         stderrLevel,
+		FileLevel:           logger.LevelDebug,
+		FileWriter:          fileWriter,
+		StderrOmitTimestamp: !verbose,
+		FileOmitTimestamp:   false,
+	})
+}